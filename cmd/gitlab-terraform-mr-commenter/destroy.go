@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/constants"
+	"gitlab-terraform-mr-commenter/internal/formatter"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// destroyDiscussionKey identifies the discussion asking for destructive
+// changes to be acknowledged.
+const destroyDiscussionKey = "destroy"
+
+// syncDestroyDiscussion keeps a resolvable discussion open while the plans
+// destroy or replace resources. Once resolved, the discussion is reopened
+// only when the set of destroyed and replaced resources changes, and it is
+// resolved automatically when the plans are no longer destructive.
+func syncDestroyDiscussion(ctx context.Context, gitlabClient GitLabCommenter, planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, existing []*types.MRDiscussion, namespace string) error {
+	var previous *types.MRDiscussion
+	for _, discussion := range existing {
+		if discussion.Key == destroyDiscussionKey {
+			previous = discussion
+			break
+		}
+	}
+
+	destructive := multiPlanData.HasDestructiveChanges()
+	if !destructive && previous == nil {
+		return nil
+	}
+
+	body, err := destroyDiscussionBody(planFormatter, multiPlanData, namespace)
+	if err != nil {
+		return err
+	}
+	hash := destroySetHash(multiPlanData)
+
+	if previous == nil {
+		if err := gitlabClient.CreateDiscussion(ctx, body, nil); err != nil {
+			return fmt.Errorf("error creating destroy discussion: %w", err)
+		}
+		slog.Info("opened destroy discussion")
+		return nil
+	}
+
+	acknowledged := recordedDestroySet(previous.Body)
+	if gitlabClient.ShouldUpdateNote(previous.Body, body) {
+		if err := gitlabClient.UpdateDiscussion(ctx, previous, body); err != nil {
+			return fmt.Errorf("error updating destroy discussion: %w", err)
+		}
+		slog.Info("updated destroy discussion", "discussion_id", previous.ID)
+	}
+
+	switch {
+	case !destructive && !previous.Resolved:
+		if err := gitlabClient.ResolveDiscussion(ctx, previous, true); err != nil {
+			return fmt.Errorf("error resolving destroy discussion: %w", err)
+		}
+		slog.Info("resolved destroy discussion, plans are no longer destructive", "discussion_id", previous.ID)
+	case destructive && previous.Resolved && acknowledged != hash:
+		if err := gitlabClient.ResolveDiscussion(ctx, previous, false); err != nil {
+			return fmt.Errorf("error reopening destroy discussion: %w", err)
+		}
+		slog.Info("reopened destroy discussion, destructive changes differ from the acknowledged ones", "discussion_id", previous.ID)
+	}
+	return nil
+}
+
+// destroyDiscussionBody renders the discussion, recording the set of
+// destroyed and replaced resources it lists.
+func destroyDiscussionBody(planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, namespace string) (string, error) {
+	content, err := planFormatter.FormatDestroyDiscussion(multiPlanData)
+	if err != nil {
+		return "", fmt.Errorf("error formatting destroy discussion: %w", err)
+	}
+	return discussionMarker(namespace, destroyDiscussionKey) + "\n" +
+		fmt.Sprintf(constants.DestroySetMarkerFormat, destroySetHash(multiPlanData)) + "\n" + content, nil
+}
+
+// destroySetHash identifies the set of resources the plans destroy or
+// replace, so that an acknowledgement holds until that set changes.
+func destroySetHash(multiPlanData *terraform.MultiPlanData) string {
+	var entries []string
+	for _, plan := range multiPlanData.Plans {
+		for _, resources := range [][]*terraform.ResourceData{plan.Data.RecreatedResources, plan.Data.DeletedResources} {
+			for _, resource := range resources {
+				for _, member := range resource.Members() {
+					entries = append(entries, plan.Name+"\x00"+member.Address+"\x00"+member.Action)
+				}
+			}
+		}
+	}
+	slices.Sort(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func recordedDestroySet(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		var hash string
+		if _, err := fmt.Sscanf(line, constants.DestroySetMarkerFormat, &hash); err == nil {
+			return hash
+		}
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/formatter"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestSyncDestroyDiscussion(t *testing.T) {
+	planFormatter, err := formatter.New("")
+	if err != nil {
+		t.Fatalf("formatter.New() error = %v", err)
+	}
+	bodyFor := func(multiPlanData *terraform.MultiPlanData) string {
+		body, err := destroyDiscussionBody(planFormatter, multiPlanData, "prod")
+		if err != nil {
+			t.Fatalf("destroyDiscussionBody() error = %v", err)
+		}
+		return body
+	}
+	acknowledged := testPlans("aws_s3_bucket.logs")
+
+	tests := []struct {
+		name      string
+		plans     *terraform.MultiPlanData
+		existing  []*types.MRDiscussion
+		wantCalls []string
+	}{
+		{
+			name:      "opens the discussion for destructive plans",
+			plans:     testPlans("aws_s3_bucket.logs"),
+			wantCalls: []string{"create discussion"},
+		},
+		{
+			name:  "opens nothing for safe plans",
+			plans: testPlans(),
+		},
+		{
+			name:      "keeps the discussion resolved while the set is unchanged",
+			plans:     testPlans("aws_s3_bucket.logs"),
+			existing:  []*types.MRDiscussion{{ID: "d1", Key: destroyDiscussionKey, Body: bodyFor(acknowledged), Resolved: true}},
+			wantCalls: nil,
+		},
+		{
+			name:      "reopens the discussion when the set changes",
+			plans:     testPlans("aws_s3_bucket.logs", "aws_sqs_queue.jobs"),
+			existing:  []*types.MRDiscussion{{ID: "d1", Key: destroyDiscussionKey, Body: bodyFor(acknowledged), Resolved: true}},
+			wantCalls: []string{"update discussion d1", "reopen discussion d1"},
+		},
+		{
+			name:      "updates an open discussion without reopening it",
+			plans:     testPlans("aws_s3_bucket.logs", "aws_sqs_queue.jobs"),
+			existing:  []*types.MRDiscussion{{ID: "d1", Key: destroyDiscussionKey, Body: bodyFor(acknowledged)}},
+			wantCalls: []string{"update discussion d1"},
+		},
+		{
+			name:      "resolves the discussion when the plans are no longer destructive",
+			plans:     testPlans(),
+			existing:  []*types.MRDiscussion{{ID: "d1", Key: destroyDiscussionKey, Body: bodyFor(acknowledged)}},
+			wantCalls: []string{"update discussion d1", "resolve discussion d1"},
+		},
+		{
+			name:      "ignores other discussions",
+			plans:     testPlans(),
+			existing:  []*types.MRDiscussion{{ID: "d2", Key: "resource-0123456789abcdef", Body: "other"}},
+			wantCalls: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeGitLab{}
+			if err := syncDestroyDiscussion(context.Background(), client, planFormatter, tt.plans, tt.existing, "prod"); err != nil {
+				t.Fatalf("syncDestroyDiscussion() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDestroySetHash(t *testing.T) {
+	ordered := testPlans("aws_s3_bucket.logs", "aws_sqs_queue.jobs")
+	reversed := testPlans("aws_sqs_queue.jobs", "aws_s3_bucket.logs")
+	if destroySetHash(ordered) != destroySetHash(reversed) {
+		t.Error("destroySetHash() depends on the order of the resources")
+	}
+	if destroySetHash(ordered) == destroySetHash(testPlans("aws_s3_bucket.logs")) {
+		t.Error("destroySetHash() is the same for different sets")
+	}
+}
+
+func TestRecordedDestroySet(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "recorded",
+			body: "<!-- gitlab-terraform-mr-commenter discussion prod/destroy -->\n<!-- gitlab-terraform-mr-commenter destroys abc123 -->\ntext",
+			want: "abc123",
+		},
+		{
+			name: "missing",
+			body: "<!-- gitlab-terraform-mr-commenter discussion prod/destroy -->\ntext",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recordedDestroySet(tt.body); got != tt.want {
+				t.Errorf("recordedDestroySet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/constants"
+	"gitlab-terraform-mr-commenter/internal/formatter"
+	"gitlab-terraform-mr-commenter/internal/source"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// inlineKeyPrefix starts the key of every inline discussion, telling them
+// apart from other discussions the commenter opens.
+const inlineKeyPrefix = "resource-"
+
+// inlineDiscussion is a diff discussion this run wants on the merge request.
+type inlineDiscussion struct {
+	key      string
+	body     string
+	position *types.DiffPosition
+}
+
+// syncDiscussions opens a diff discussion on the block declaring each
+// recreated or destroyed resource, updates the discussions of previous runs
+// that still apply and retires those that no longer do. changes is nil when
+// the merge request changes were not fetched.
+func syncDiscussions(ctx context.Context, gitlabClient GitLabCommenter, planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, changes *types.MRChanges, existing []*types.MRDiscussion, namespace string) error {
+	// A discussion retired with replies keeps its key, so the latest
+	// discussion for a key is the one kept up to date.
+	existingByKey := make(map[string]*types.MRDiscussion)
+	var retired []*types.MRDiscussion
+	for _, discussion := range existing {
+		if !strings.HasPrefix(discussion.Key, inlineKeyPrefix) {
+			continue
+		}
+		if previous, ok := existingByKey[discussion.Key]; ok {
+			if previous.NoteID > discussion.NoteID {
+				retired = append(retired, discussion)
+				continue
+			}
+			retired = append(retired, previous)
+		}
+		existingByKey[discussion.Key] = discussion
+	}
+
+	var wanted []*inlineDiscussion
+	if changes != nil {
+		var err error
+		wanted, err = inlineDiscussions(planFormatter, multiPlanData, changes, namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, discussion := range wanted {
+		previous, ok := existingByKey[discussion.key]
+		delete(existingByKey, discussion.key)
+		if err := updateOrCreateDiscussion(ctx, gitlabClient, previous, ok, discussion); err != nil {
+			return err
+		}
+	}
+
+	for _, stale := range existingByKey {
+		retired = append(retired, stale)
+	}
+	for _, stale := range retired {
+		if err := retireDiscussion(ctx, gitlabClient, stale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retireDiscussion removes a discussion that no longer applies. Deleting its
+// first note would leave the replies as an open thread without the marker,
+// so a discussion someone replied to is resolved instead.
+func retireDiscussion(ctx context.Context, gitlabClient GitLabCommenter, discussion *types.MRDiscussion) error {
+	if !discussion.Replied {
+		if err := gitlabClient.DeleteDiscussion(ctx, discussion); err != nil {
+			return fmt.Errorf("error deleting discussion: %w", err)
+		}
+		slog.Info("deleted stale discussion", "discussion_id", discussion.ID)
+		return nil
+	}
+	if discussion.Resolved {
+		return nil
+	}
+	if err := gitlabClient.ResolveDiscussion(ctx, discussion, true); err != nil {
+		return fmt.Errorf("error resolving discussion: %w", err)
+	}
+	slog.Info("resolved stale discussion with replies", "discussion_id", discussion.ID)
+	return nil
+}
+
+func updateOrCreateDiscussion(ctx context.Context, gitlabClient GitLabCommenter, previous *types.MRDiscussion, exists bool, discussion *inlineDiscussion) error {
+	if exists && samePosition(previous.Position, discussion.position) {
+		if !gitlabClient.ShouldUpdateNote(previous.Body, discussion.body) {
+			return nil
+		}
+		if err := gitlabClient.UpdateDiscussion(ctx, previous, discussion.body); err != nil {
+			return fmt.Errorf("error updating discussion: %w", err)
+		}
+		slog.Info("updated discussion", "discussion_id", previous.ID)
+		return nil
+	}
+
+	// A discussion cannot be moved, so one on a line that no longer declares
+	// the block is replaced.
+	if exists {
+		if err := retireDiscussion(ctx, gitlabClient, previous); err != nil {
+			return err
+		}
+	}
+	if err := gitlabClient.CreateDiscussion(ctx, discussion.body, discussion.position); err != nil {
+		return fmt.Errorf("error creating discussion: %w", err)
+	}
+	slog.Info("created discussion", "path", discussion.position.NewPath, "line", discussion.position.NewLine, "old_line", discussion.position.OldLine)
+	return nil
+}
+
+// samePosition reports whether two positions are on the same line: the same
+// new line, or the same old line for removed lines.
+func samePosition(a, b *types.DiffPosition) bool {
+	if a == nil || b == nil || a.NewPath != b.NewPath || a.NewLine != b.NewLine {
+		return false
+	}
+	return a.NewLine > 0 || a.OldLine == b.OldLine
+}
+
+// inlineDiscussions builds one discussion per block declaring recreated or
+// destroyed resources, for the blocks the merge request diff shows. Blocks the
+// merge request removes are anchored to their removed opening line.
+func inlineDiscussions(planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, changes *types.MRChanges, namespace string) ([]*inlineDiscussion, error) {
+	diff := source.NewDiff(changes.Files)
+	removed := source.RemovedBlocks(changes.Files)
+	oldPaths := make(map[string]string, len(changes.Files))
+	for _, file := range changes.Files {
+		oldPaths[file.NewPath] = file.OldPath
+	}
+
+	var result []*inlineDiscussion
+	for _, plan := range multiPlanData.Plans {
+		moduleDirs := moduleDirs(plan)
+		for _, block := range destructiveBlocks(plan) {
+			position := &types.DiffPosition{
+				BaseSHA:  changes.BaseSHA,
+				StartSHA: changes.StartSHA,
+				HeadSHA:  changes.HeadSHA,
+			}
+			if location := block.Resources[0].Source; location != nil {
+				line, diffLine, ok := diff.Position(location)
+				if !ok {
+					slog.Info("declaring block not in merge request diff, skipping discussion", "address", block.Address, "file", location.File)
+					continue
+				}
+				position.OldPath = oldPaths[location.File]
+				position.NewPath = location.File
+				position.NewLine = line
+				if !diffLine.Added {
+					position.OldLine = diffLine.OldLine
+				}
+			} else {
+				removedBlock, ok := findRemovedBlock(block.Address, removed, moduleDirs)
+				if !ok {
+					slog.Info("declaring block not found in merge request diff, skipping discussion", "address", block.Address)
+					continue
+				}
+				position.OldPath = removedBlock.OldPath
+				position.NewPath = removedBlock.NewPath
+				position.OldLine = removedBlock.OldLine
+			}
+
+			body, err := planFormatter.FormatDiscussion(block)
+			if err != nil {
+				return nil, fmt.Errorf("error formatting discussion: %w", err)
+			}
+			key := discussionKey(plan.Name, block.Address)
+			result = append(result, &inlineDiscussion{
+				key:      key,
+				body:     discussionMarker(namespace, key) + "\n" + body,
+				position: position,
+			})
+		}
+	}
+	return result, nil
+}
+
+// destructiveBlocks groups the recreated and destroyed resources of a plan by
+// the block declaring them, in plan order.
+func destructiveBlocks(plan *terraform.PlanWithIdentifier) []*formatter.Discussion {
+	var result []*formatter.Discussion
+	byAddress := make(map[string]*formatter.Discussion)
+	for _, resources := range [][]*terraform.ResourceData{plan.Data.RecreatedResources, plan.Data.DeletedResources} {
+		for _, resource := range resources {
+			address := resource.ConfigAddress()
+			block, ok := byAddress[address]
+			if !ok {
+				block = &formatter.Discussion{Plan: plan.Name, Address: address}
+				byAddress[address] = block
+				result = append(result, block)
+			}
+			block.Resources = append(block.Resources, resource)
+		}
+	}
+	return result
+}
+
+// moduleDirs returns the directory declaring each module of the plan, as far
+// as the located resources tell, keyed by module prefix such as
+// "module.network.".
+func moduleDirs(plan *terraform.PlanWithIdentifier) map[string]string {
+	dirs := make(map[string]string)
+	for _, resources := range [][]*terraform.ResourceData{
+		plan.Data.CreatedResources,
+		plan.Data.UpdatedResources,
+		plan.Data.RecreatedResources,
+		plan.Data.DeletedResources,
+	} {
+		for _, resource := range resources {
+			if resource.Source == nil {
+				continue
+			}
+			prefix, _ := splitModulePrefix(resource.ConfigAddress())
+			dirs[prefix] = path.Dir(resource.Source.File)
+		}
+	}
+	return dirs
+}
+
+// findRemovedBlock picks the removed block declaring a resource: the one in
+// the directory of the resource's module when that is known, or else the
+// only block removed under the resource's local address.
+func findRemovedBlock(address string, removed map[string][]source.RemovedBlock, moduleDirs map[string]string) (source.RemovedBlock, bool) {
+	prefix, local := splitModulePrefix(address)
+	candidates := removed[local]
+	if dir, ok := moduleDirs[prefix]; ok {
+		var inDir []source.RemovedBlock
+		for _, candidate := range candidates {
+			if path.Dir(candidate.OldPath) == dir {
+				inDir = append(inDir, candidate)
+			}
+		}
+		candidates = inDir
+	}
+	if len(candidates) != 1 {
+		return source.RemovedBlock{}, false
+	}
+	return candidates[0], true
+}
+
+// splitModulePrefix splits a configuration address such as
+// module.network.aws_subnet.private into its module prefix "module.network."
+// and its address within the module.
+func splitModulePrefix(address string) (string, string) {
+	var prefix string
+	for strings.HasPrefix(address, "module.") {
+		_, rest, _ := strings.Cut(strings.TrimPrefix(address, "module."), ".")
+		prefix += address[:len(address)-len(rest)]
+		address = rest
+	}
+	return prefix, address
+}
+
+// discussionMarker starts the first note of a discussion, recording its key
+// in the commenter's namespace.
+func discussionMarker(namespace, key string) string {
+	return fmt.Sprintf(constants.DiscussionMarkerFormat, namespace+"/"+key)
+}
+
+func discussionKey(planName, address string) string {
+	sum := sha256.Sum256([]byte(planName + "\x00" + address))
+	return inlineKeyPrefix + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/formatter"
+	"gitlab-terraform-mr-commenter/internal/source"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+const bucketDiff = `@@ -1,3 +1,3 @@
+ resource "aws_s3_bucket" "logs" {
+-  bucket = "logs-old"
++  bucket = "logs-new"
+ }
+`
+
+func TestSyncDiscussions(t *testing.T) {
+	planFormatter, err := formatter.New("")
+	if err != nil {
+		t.Fatalf("formatter.New() error = %v", err)
+	}
+	plans := testPlans("aws_s3_bucket.logs")
+	bucket := plans.Plans[0].Data.DeletedResources[0]
+	bucket.Source = &terraform.SourceLocation{File: "main.tf", Line: 1, EndLine: 3}
+	changes := &types.MRChanges{
+		BaseSHA: "base",
+		HeadSHA: "head",
+		Files:   []*types.ChangedFile{{OldPath: "main.tf", NewPath: "main.tf", Diff: bucketDiff}},
+	}
+	key := discussionKey("prod", bucket.ConfigAddress())
+	wanted, err := inlineDiscussions(planFormatter, plans, changes, "prod")
+	if err != nil || len(wanted) != 1 {
+		t.Fatalf("inlineDiscussions() = %d discussions, %v, want 1", len(wanted), err)
+	}
+	current := wanted[0]
+	at := func(line int) *types.DiffPosition {
+		return &types.DiffPosition{NewPath: "main.tf", NewLine: line}
+	}
+
+	tests := []struct {
+		name      string
+		changes   *types.MRChanges
+		existing  []*types.MRDiscussion
+		wantCalls []string
+	}{
+		{
+			name:      "opens a discussion on the first changed line",
+			changes:   changes,
+			wantCalls: []string{"create discussion at main.tf:2"},
+		},
+		{
+			name:     "leaves an up to date discussion alone",
+			changes:  changes,
+			existing: []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(2)}},
+		},
+		{
+			name:      "updates a discussion in place",
+			changes:   changes,
+			existing:  []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: "old", Position: at(2)}},
+			wantCalls: []string{"update discussion d1"},
+		},
+		{
+			name:      "replaces a moved discussion",
+			changes:   changes,
+			existing:  []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(7)}},
+			wantCalls: []string{"delete discussion d1", "create discussion at main.tf:2"},
+		},
+		{
+			name:      "resolves a moved discussion with replies",
+			changes:   changes,
+			existing:  []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(7), Replied: true}},
+			wantCalls: []string{"resolve discussion d1", "create discussion at main.tf:2"},
+		},
+		{
+			name:    "keeps the latest discussion for a key",
+			changes: changes,
+			existing: []*types.MRDiscussion{
+				{ID: "d2", NoteID: 2, Key: key, Body: current.body, Position: at(2)},
+				{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(7), Replied: true, Resolved: true},
+			},
+		},
+		{
+			name:      "deletes a stale discussion",
+			existing:  []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(2)}},
+			wantCalls: []string{"delete discussion d1"},
+		},
+		{
+			name:      "resolves a stale discussion with replies",
+			existing:  []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(2), Replied: true}},
+			wantCalls: []string{"resolve discussion d1"},
+		},
+		{
+			name:     "leaves a resolved stale discussion with replies alone",
+			existing: []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: current.body, Position: at(2), Replied: true, Resolved: true}},
+		},
+		{
+			name:     "ignores other discussions",
+			existing: []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: destroyDiscussionKey, Body: "destroy"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeGitLab{}
+			if err := syncDiscussions(context.Background(), client, planFormatter, plans, tt.changes, tt.existing, "prod"); err != nil {
+				t.Fatalf("syncDiscussions() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const removedBucketDiff = `@@ -1,7 +1,3 @@
+ resource "aws_s3_bucket" "data" {
+   bucket = "data"
+ }
+-
+-resource "aws_s3_bucket" "logs" {
+-  bucket = "logs"
+-}
+`
+
+func TestSyncDiscussionsRemovedBlock(t *testing.T) {
+	planFormatter, err := formatter.New("")
+	if err != nil {
+		t.Fatalf("formatter.New() error = %v", err)
+	}
+	plans := testPlans("aws_s3_bucket.logs")
+	terraform.SetConfigAddress(plans.Plans[0].Data.DeletedResources[0], "aws_s3_bucket.logs")
+	changes := &types.MRChanges{
+		BaseSHA: "base",
+		HeadSHA: "head",
+		Files:   []*types.ChangedFile{{OldPath: "main.tf", NewPath: "main.tf", Diff: removedBucketDiff}},
+	}
+	removed := &types.DiffPosition{OldPath: "main.tf", NewPath: "main.tf", OldLine: 5}
+	key := discussionKey("prod", "aws_s3_bucket.logs")
+	wanted, err := inlineDiscussions(planFormatter, plans, changes, "prod")
+	if err != nil || len(wanted) != 1 {
+		t.Fatalf("inlineDiscussions() = %d discussions, %v, want 1", len(wanted), err)
+	}
+
+	tests := []struct {
+		name      string
+		existing  []*types.MRDiscussion
+		wantCalls []string
+	}{
+		{
+			name:      "opens a discussion on the removed line",
+			wantCalls: []string{"create discussion at main.tf:-5"},
+		},
+		{
+			name:     "leaves an up to date discussion alone",
+			existing: []*types.MRDiscussion{{ID: "d1", NoteID: 1, Key: key, Body: wanted[0].body, Position: removed}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeGitLab{}
+			if err := syncDiscussions(context.Background(), client, planFormatter, plans, changes, tt.existing, "prod"); err != nil {
+				t.Fatalf("syncDiscussions() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindRemovedBlock(t *testing.T) {
+	removed := map[string][]source.RemovedBlock{
+		"aws_subnet.private": {
+			{OldPath: "modules/network/subnets.tf", NewPath: "modules/network/subnets.tf", OldLine: 4},
+			{OldPath: "modules/legacy/subnets.tf", NewPath: "modules/legacy/subnets.tf", OldLine: 9},
+		},
+		"aws_s3_bucket.logs": {{OldPath: "main.tf", NewPath: "main.tf", OldLine: 1}},
+	}
+	moduleDirs := map[string]string{"module.network.": "modules/network"}
+
+	tests := []struct {
+		address string
+		want    source.RemovedBlock
+		wantOK  bool
+	}{
+		{address: "aws_s3_bucket.logs", want: removed["aws_s3_bucket.logs"][0], wantOK: true},
+		{address: "module.network.aws_subnet.private", want: removed["aws_subnet.private"][0], wantOK: true},
+		{address: "module.storage.aws_subnet.private"},
+		{address: "aws_s3_bucket.data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, ok := findRemovedBlock(tt.address, removed, moduleDirs)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("findRemovedBlock() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
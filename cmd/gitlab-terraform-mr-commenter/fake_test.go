@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// fakeGitLab is an in-memory GitLabCommenter recording the calls that change
+// the merge request.
+type fakeGitLab struct {
+	notes       []*types.MRNote
+	discussions []*types.MRDiscussion
+	calls       []string
+	nextID      int64
+}
+
+func (f *fakeGitLab) record(format string, args ...interface{}) {
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeGitLab) ValidateAccess(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeGitLab) FindPlanNotes(ctx context.Context) ([]*types.MRNote, error) {
+	f.record("list notes")
+	return f.notes, nil
+}
+
+func (f *fakeGitLab) GetPlanNotes(ctx context.Context, noteIDs []int64) ([]*types.MRNote, error) {
+	f.record("get notes %v", noteIDs)
+	var result []*types.MRNote
+	for _, id := range noteIDs {
+		note := f.note(id)
+		if note == nil {
+			return nil, nil
+		}
+		result = append(result, note)
+	}
+	return result, nil
+}
+
+func (f *fakeGitLab) note(id int64) *types.MRNote {
+	for _, note := range f.notes {
+		if note.ID == id {
+			return note
+		}
+	}
+	return nil
+}
+
+func (f *fakeGitLab) ShouldUpdateNote(existingBody, newBody string) bool {
+	return strings.Join(strings.Fields(existingBody), " ") != strings.Join(strings.Fields(newBody), " ")
+}
+
+func (f *fakeGitLab) UpdateNote(ctx context.Context, noteID int64, body string) error {
+	f.record("update note %d", noteID)
+	return nil
+}
+
+func (f *fakeGitLab) CreateNote(ctx context.Context, body string) (int64, error) {
+	f.nextID++
+	id := 100 + f.nextID
+	f.record("create note %d", id)
+	return id, nil
+}
+
+func (f *fakeGitLab) DeleteNote(ctx context.Context, noteID int64) error {
+	f.record("delete note %d", noteID)
+	return nil
+}
+
+func (f *fakeGitLab) UploadFile(ctx context.Context, filename string, content []byte) (string, error) {
+	f.record("upload %s", filename)
+	return "/uploads/" + filename, nil
+}
+
+func (f *fakeGitLab) MergeRequestChanges(ctx context.Context) (*types.MRChanges, error) {
+	return &types.MRChanges{}, nil
+}
+
+func (f *fakeGitLab) FindDiscussions(ctx context.Context) ([]*types.MRDiscussion, error) {
+	return f.discussions, nil
+}
+
+func (f *fakeGitLab) CreateDiscussion(ctx context.Context, body string, position *types.DiffPosition) error {
+	if position != nil && position.NewLine == 0 {
+		f.record("create discussion at %s:-%d", position.OldPath, position.OldLine)
+		return nil
+	}
+	if position != nil {
+		f.record("create discussion at %s:%d", position.NewPath, position.NewLine)
+		return nil
+	}
+	f.record("create discussion")
+	return nil
+}
+
+func (f *fakeGitLab) UpdateDiscussion(ctx context.Context, discussion *types.MRDiscussion, body string) error {
+	f.record("update discussion %s", discussion.ID)
+	return nil
+}
+
+func (f *fakeGitLab) ResolveDiscussion(ctx context.Context, discussion *types.MRDiscussion, resolved bool) error {
+	if resolved {
+		f.record("resolve discussion %s", discussion.ID)
+	} else {
+		f.record("reopen discussion %s", discussion.ID)
+	}
+	return nil
+}
+
+func (f *fakeGitLab) DeleteDiscussion(ctx context.Context, discussion *types.MRDiscussion) error {
+	f.record("delete discussion %s", discussion.ID)
+	return nil
+}
+
+// testPlans returns a single plan deleting the resources at the given
+// addresses and updating one more.
+func testPlans(deleted ...string) *terraform.MultiPlanData {
+	data := &terraform.PlanData{
+		HasChanges:       true,
+		Summary:          terraform.ChangeSummary{Update: 1, Delete: len(deleted)},
+		UpdatedResources: []*terraform.ResourceData{{Address: "aws_instance.web", Action: "update", Diff: "-ami = \"one\"\n+ami = \"two\""}},
+	}
+	for _, address := range deleted {
+		data.DeletedResources = append(data.DeletedResources, &terraform.ResourceData{
+			Address: address,
+			Action:  "delete",
+			Diff:    "-name = \"" + address + "\"",
+		})
+	}
+	return &terraform.MultiPlanData{
+		HasChanges: true,
+		Summary:    data.Summary,
+		Plans:      []*terraform.PlanWithIdentifier{{Name: "prod", Path: "prod/plan.json", Data: data}},
+	}
+}
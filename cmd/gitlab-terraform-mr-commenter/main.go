@@ -1,20 +1,26 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 
 	"gitlab-terraform-mr-commenter/internal/config"
 	"gitlab-terraform-mr-commenter/internal/constants"
+	"gitlab-terraform-mr-commenter/internal/cost"
 	"gitlab-terraform-mr-commenter/internal/formatter"
 	"gitlab-terraform-mr-commenter/internal/gitlab"
 	"gitlab-terraform-mr-commenter/internal/output"
+	"gitlab-terraform-mr-commenter/internal/source"
 	"gitlab-terraform-mr-commenter/internal/terraform"
 	"gitlab-terraform-mr-commenter/internal/types"
 )
@@ -23,20 +29,131 @@ const noChangesMessage = "No changes detected."
 
 type GitLabCommenter interface {
 	ValidateAccess(ctx context.Context) error
-	FindExistingPlanNote(ctx context.Context) (*types.MRNote, error)
+	FindPlanNotes(ctx context.Context) ([]*types.MRNote, error)
+	GetPlanNotes(ctx context.Context, noteIDs []int64) ([]*types.MRNote, error)
 	ShouldUpdateNote(existingBody, newBody string) bool
 	UpdateNote(ctx context.Context, noteID int64, body string) error
-	CreateNote(ctx context.Context, body string) error
+	CreateNote(ctx context.Context, body string) (int64, error)
+	DeleteNote(ctx context.Context, noteID int64) error
+	UploadFile(ctx context.Context, filename string, content []byte) (string, error)
+	MergeRequestChanges(ctx context.Context) (*types.MRChanges, error)
+	FindDiscussions(ctx context.Context) ([]*types.MRDiscussion, error)
+	CreateDiscussion(ctx context.Context, body string, position *types.DiffPosition) error
+	UpdateDiscussion(ctx context.Context, discussion *types.MRDiscussion, body string) error
+	ResolveDiscussion(ctx context.Context, discussion *types.MRDiscussion, resolved bool) error
+	DeleteDiscussion(ctx context.Context, discussion *types.MRDiscussion) error
 }
 
-func withMarker(body string) string {
-	return constants.NoteMarker + "\n" + body
+type options struct {
+	outputs           outputList
+	priceCatalog      string
+	infracostFiles    stringList
+	graph             bool
+	groupBy           string
+	byProvider        bool
+	templatePath      string
+	allowedDestroys   stringList
+	maxNoteLength     int
+	budget            int
+	reportURL         string
+	uploadReport      string
+	sourceDir         string
+	projectDir        string
+	projectURL        string
+	commitSHA         string
+	inlineDiscussions bool
+	blockDestroy      bool
+	noteVisibility    string
+	noteStyle         string
+	noteCache         string
+	mergeRequest      string
+	namespace         string
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// outputList is a flag.Value collecting every -output target.
+type outputList []output.Target
+
+func (l *outputList) String() string {
+	parts := make([]string, len(*l))
+	for i, target := range *l {
+		parts[i] = target.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *outputList) Set(value string) error {
+	target, err := output.ParseTarget(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, target)
+	return nil
+}
+
+// writesComment reports whether the Markdown comment goes to a file or
+// stdout instead of the merge request.
+func (l outputList) writesComment() bool {
+	return slices.ContainsFunc(l, func(target output.Target) bool {
+		return target.Format == output.FormatMarkdown
+	})
+}
+
+// withMarker prefixes the note with the marker used to find it again, and
+// with the part marker when the comment spans several notes.
+func withMarker(body, namespace string, part, parts int) string {
+	noteMarker := fmt.Sprintf(constants.NamespacedNoteMarkerFormat, namespace)
+	if parts > 1 {
+		return noteMarker + "\n" + fmt.Sprintf(constants.PartMarkerFormat, part, parts) + "\n" + body
+	}
+	return noteMarker + "\n" + body
+}
+
+// stripMarkers removes the markers added by withMarker. The marker of a note
+// posted before markers were namespaced is kept, so that the note is updated
+// and taken over by the namespace.
+func stripMarkers(body, namespace string) string {
+	body = strings.TrimPrefix(body, fmt.Sprintf(constants.NamespacedNoteMarkerFormat, namespace)+"\n")
+	if marker, rest, ok := strings.Cut(body, "\n"); ok && strings.HasPrefix(marker, "<!-- ") {
+		var part, parts int
+		if _, err := fmt.Sscanf(marker, constants.PartMarkerFormat, &part, &parts); err == nil {
+			return rest
+		}
+	}
+	return body
 }
 
 func main() {
-	var outputFile string
+	var opts options
 
-	flag.StringVar(&outputFile, "output", "", "Write output to file (use '-' for stdout)")
+	flag.Var(&opts.outputs, "output", "Write output to file as [format:]path, format one of md, json, text, html, terraform, codequality, junit (use '-' for stdout, repeatable)")
+	flag.StringVar(&opts.priceCatalog, "price-catalog", "", "YAML or JSON price catalog used to estimate monthly cost changes")
+	flag.Var(&opts.infracostFiles, "infracost", "Infracost breakdown JSON file to take cost changes from (repeatable)")
+	flag.BoolVar(&opts.graph, "graph", false, "Include a Mermaid dependency graph of the changed resources")
+	flag.BoolVar(&opts.byProvider, "by-provider", false, "Add a per-provider breakdown next to the per-resource-type summary")
+	flag.StringVar(&opts.groupBy, "group-by", terraform.GroupByAction, "Group resource changes by 'action' or 'module'")
+	flag.Var(&opts.allowedDestroys, "allow-destroy", "Address glob of a resource allowed to be destroyed in the JUnit report (repeatable, adds to ALLOWED_DESTROYS)")
+	flag.IntVar(&opts.budget, "budget", 0, "Reduce detail until the comment fits in this many characters (overrides COMMENT_BUDGET, 0 disables)")
+	flag.StringVar(&opts.uploadReport, "upload-report", "", "Upload the full report as 'md', 'html' or 'json' and link it from the note (overrides UPLOAD_REPORT)")
+	flag.StringVar(&opts.sourceDir, "source-dir", "", "Terraform configuration directory to link resources to (overrides TERRAFORM_SOURCE_DIR, defaults to each plan's directory)")
+	flag.BoolVar(&opts.inlineDiscussions, "inline-discussions", false, "Open a diff discussion on the block declaring each recreated or destroyed resource (or set INLINE_DISCUSSIONS)")
+	flag.BoolVar(&opts.blockDestroy, "block-destroy", false, "Open a resolvable discussion that must be resolved to acknowledge destructive changes (or set BLOCK_DESTROY)")
+	flag.StringVar(&opts.noteVisibility, "note-visibility", "", "Post the comment as an 'internal' or 'public' note (overrides NOTE_VISIBILITY, default internal)")
+	flag.StringVar(&opts.noteStyle, "note-style", "", "Post the comment as a standalone 'note' or a resolvable 'discussion' (overrides NOTE_STYLE, default note)")
+	flag.StringVar(&opts.noteCache, "note-cache", "", "File remembering the IDs of the posted notes, to find them again without listing every note (overrides NOTE_CACHE_FILE)")
+	flag.StringVar(&opts.namespace, "namespace", "", "Name telling apart the notes of several commenters on one merge request (overrides COMMENTER_NAMESPACE, defaults to CI_JOB_NAME or the plan files)")
+	flag.StringVar(&opts.templatePath, "template", "", "Custom comment template file or directory of *.tmpl files (overrides TEMPLATE_PATH)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] <terraform-plan.json> [<terraform-plan2.json> ...]\n\n", os.Args[0])
@@ -47,6 +164,19 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  GITLAB_URL        GitLab instance URL (default: https://gitlab.com)\n")
 		fmt.Fprintf(os.Stderr, "  GITLAB_PROJECT_ID GitLab project ID (required)\n")
 		fmt.Fprintf(os.Stderr, "  GITLAB_MR_ID      GitLab merge request ID (required)\n")
+		fmt.Fprintf(os.Stderr, "  TEMPLATE_PATH     Custom comment template file or directory\n")
+		fmt.Fprintf(os.Stderr, "  ALLOWED_DESTROYS  Comma-separated address globs allowed to be destroyed\n")
+		fmt.Fprintf(os.Stderr, "  COMMENT_BUDGET    Reduce detail until the comment fits in this many characters\n")
+		fmt.Fprintf(os.Stderr, "  FULL_REPORT_URL   Link to the full report shown when detail is reduced\n")
+		fmt.Fprintf(os.Stderr, "  UPLOAD_REPORT     Upload the full report as md, html or json and link it from the note\n")
+		fmt.Fprintf(os.Stderr, "  TERRAFORM_SOURCE_DIR  Terraform configuration directory to link resources to\n")
+		fmt.Fprintf(os.Stderr, "  INLINE_DISCUSSIONS  Open a diff discussion on the block declaring each destructive change\n")
+		fmt.Fprintf(os.Stderr, "  BLOCK_DESTROY     Open a resolvable discussion acknowledging destructive changes\n")
+		fmt.Fprintf(os.Stderr, "  NOTE_VISIBILITY   Post the comment as an internal or public note (default: internal)\n")
+		fmt.Fprintf(os.Stderr, "  NOTE_STYLE        Post the comment as a standalone note or a discussion (default: note)\n")
+		fmt.Fprintf(os.Stderr, "  NOTE_CACHE_FILE   File remembering the IDs of the posted notes\n")
+		fmt.Fprintf(os.Stderr, "  COMMENTER_NAMESPACE  Name telling apart the notes of several commenters (default: CI_JOB_NAME or the plan files)\n")
+		fmt.Fprintf(os.Stderr, "  MAX_NOTE_LENGTH   Split the comment into notes of at most this many bytes (default: 1000000)\n")
 	}
 
 	flag.Parse()
@@ -58,97 +188,457 @@ func main() {
 	}
 	planFiles := args
 
+	if opts.groupBy != terraform.GroupByAction && opts.groupBy != terraform.GroupByModule {
+		fmt.Fprintf(os.Stderr, "invalid -group-by value %q: must be %q or %q\n", opts.groupBy, terraform.GroupByAction, terraform.GroupByModule)
+		os.Exit(1)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	if err := run(ctx, planFiles, outputFile); err != nil {
+	if err := run(ctx, planFiles, opts); err != nil {
 		slog.Error("fatal error", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, planFiles []string, outputFile string) error {
+func run(ctx context.Context, planFiles []string, opts options) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("error loading configuration: %w", err)
 	}
+	if opts.templatePath == "" {
+		opts.templatePath = cfg.TemplatePath
+	}
+	opts.allowedDestroys = append(opts.allowedDestroys, cfg.AllowedDestroys...)
+	if cfg.MaxNoteLength <= notePartOverhead {
+		return fmt.Errorf("MAX_NOTE_LENGTH must be larger than %d", notePartOverhead)
+	}
+	opts.maxNoteLength = min(cfg.MaxNoteLength, constants.MaxNoteLength)
+	if opts.budget == 0 {
+		opts.budget = cfg.CommentBudget
+	}
+	opts.reportURL = reportURL(cfg, opts.outputs)
+	if opts.uploadReport == "" {
+		opts.uploadReport = cfg.UploadReport
+	}
+	if _, ok := reportExtensions[opts.uploadReport]; opts.uploadReport != "" && !ok {
+		return fmt.Errorf("invalid upload report format %q: must be %q, %q or %q", opts.uploadReport, output.FormatMarkdown, output.FormatHTML, output.FormatJSON)
+	}
+	if opts.sourceDir == "" {
+		opts.sourceDir = cfg.SourceDir
+	}
+	opts.projectDir = cmp.Or(cfg.CIProjectDir, ".")
+	opts.projectURL = cfg.CIProjectURL
+	opts.commitSHA = cfg.CICommitSHA
+	opts.inlineDiscussions = opts.inlineDiscussions || cfg.InlineDiscussions
+	opts.blockDestroy = opts.blockDestroy || cfg.BlockDestroy
+	cfg.NoteVisibility = cmp.Or(opts.noteVisibility, cfg.NoteVisibility)
+	cfg.NoteStyle = cmp.Or(opts.noteStyle, cfg.NoteStyle)
+	if err := validateNoteStyle(cfg.NoteVisibility, cfg.NoteStyle, opts); err != nil {
+		return err
+	}
+	opts.noteCache = cmp.Or(opts.noteCache, cfg.NoteCacheFile)
+	opts.mergeRequest = fmt.Sprintf("%s!%d", cfg.ProjectID, cfg.MergeRequestID)
+	opts.namespace = resolveNamespace(opts.namespace, cfg.Namespace, cfg.CIJobName, planFiles)
+	cfg.Namespace = opts.namespace
 
 	gitlabClient, err := gitlab.New(cfg)
 	if err != nil {
 		return fmt.Errorf("error creating GitLab client: %w", err)
 	}
 
-	return runWithClients(ctx, planFiles, outputFile, gitlabClient)
+	return runWithClients(ctx, planFiles, opts, gitlabClient)
 }
 
-func runWithClients(ctx context.Context, planFiles []string, outputFile string, gitlabClient GitLabCommenter) error {
-	commentBody, err := loadAndProcessPlans(planFiles)
+func runWithClients(ctx context.Context, planFiles []string, opts options, gitlabClient GitLabCommenter) error {
+	planFormatter, err := formatter.New(opts.templatePath)
+	if err != nil {
+		return fmt.Errorf("error loading template: %w", err)
+	}
+
+	multiPlanData, err := loadAndProcessPlans(planFiles, opts)
 	if err != nil {
 		return err
 	}
 
-	if outputFile != "" {
-		if err := output.Write(commentBody, outputFile); err != nil {
-			return fmt.Errorf("error writing output: %w", err)
+	multiPlanData.Display.ReportURL = opts.reportURL
+
+	// Writing the comment itself is a dry run; other formats are artifacts
+	// produced alongside the merge request note.
+	dryRun := opts.outputs.writesComment()
+
+	var existingNotes []*types.MRNote
+	var changes *types.MRChanges
+	var reportMarker string
+	if !dryRun {
+		existingNotes, err = findPlanNotes(ctx, gitlabClient, opts)
+		if err != nil {
+			return err
+		}
+		if hasSources(multiPlanData) || opts.inlineDiscussions {
+			changes, err = gitlabClient.MergeRequestChanges(ctx)
+			if err != nil {
+				return fmt.Errorf("error getting merge request changes: %w", err)
+			}
+			linkSources(multiPlanData, changes, opts)
+		}
+		if opts.uploadReport != "" && multiPlanData.HasChanges {
+			multiPlanData.Display.ReportURL, reportMarker, err = uploadReport(ctx, gitlabClient, planFormatter, multiPlanData, opts.uploadReport, existingNotes)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		source.NewLinker(opts.projectURL, opts.commitSHA, "", nil).LinkAll(multiPlanData)
+	}
+
+	commentBody, err := formatComment(planFormatter, multiPlanData, opts.budget)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range opts.outputs {
+		if err := writeOutput(target, commentBody, multiPlanData, opts); err != nil {
+			return err
 		}
-		output.PrintSuccess(outputFile)
+	}
+
+	if dryRun {
 		return nil
 	}
 
-	return handleGitLabComment(ctx, commentBody, gitlabClient)
+	if reportMarker != "" {
+		commentBody += "\n" + reportMarker
+	}
+	noteIDs, err := postComment(ctx, commentBody, gitlabClient, existingNotes, opts.maxNoteLength, opts.namespace)
+	if err != nil {
+		return err
+	}
+	if opts.noteCache != "" {
+		if err := saveNoteCache(opts.noteCache, opts.mergeRequest, noteIDs); err != nil {
+			slog.Warn("could not save note cache", "path", opts.noteCache, "error", err)
+		}
+	}
+
+	if !opts.inlineDiscussions && !opts.blockDestroy {
+		return nil
+	}
+	existingDiscussions, err := gitlabClient.FindDiscussions(ctx)
+	if err != nil {
+		return fmt.Errorf("error finding existing discussions: %w", err)
+	}
+	if opts.inlineDiscussions {
+		if err := syncDiscussions(ctx, gitlabClient, planFormatter, multiPlanData, changes, existingDiscussions, opts.namespace); err != nil {
+			return err
+		}
+	}
+	if opts.blockDestroy {
+		return syncDestroyDiscussion(ctx, gitlabClient, planFormatter, multiPlanData, existingDiscussions, opts.namespace)
+	}
+	return nil
 }
 
-func loadAndProcessPlans(planFiles []string) (string, error) {
+func loadAndProcessPlans(planFiles []string, opts options) (*terraform.MultiPlanData, error) {
 	multiPlanData, err := terraform.ProcessMultiplePlans(planFiles)
 	if err != nil {
-		return "", fmt.Errorf("error processing terraform plans: %w", err)
+		return nil, fmt.Errorf("error processing terraform plans: %w", err)
 	}
 
-	var commentBody string
-	if multiPlanData.HasChanges {
-		commentBody, err = formatter.FormatPlan(multiPlanData)
+	multiPlanData.Display.Graph = opts.graph
+	multiPlanData.Display.GroupBy = opts.groupBy
+	multiPlanData.Display.ByProvider = opts.byProvider
+
+	if opts.priceCatalog != "" {
+		catalog, err := cost.LoadCatalog(opts.priceCatalog)
 		if err != nil {
-			return "", fmt.Errorf("error formatting plans: %w", err)
+			return nil, fmt.Errorf("error loading price catalog: %w", err)
 		}
-	} else {
-		commentBody = noChangesMessage
+		cost.Estimate(multiPlanData, catalog)
+	}
+
+	if len(opts.infracostFiles) > 0 {
+		reports := make([]*cost.InfracostReport, 0, len(opts.infracostFiles))
+		for _, file := range opts.infracostFiles {
+			report, err := cost.LoadInfracost(file)
+			if err != nil {
+				return nil, fmt.Errorf("error loading infracost report: %w", err)
+			}
+			reports = append(reports, report)
+		}
+		cost.ApplyInfracost(multiPlanData, reports)
+	}
+
+	if err := attachSources(multiPlanData, opts); err != nil {
+		return nil, err
+	}
+
+	return multiPlanData, nil
+}
+
+func formatComment(planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, budget int) (string, error) {
+	if !multiPlanData.HasChanges {
+		return noChangesMessage, nil
 	}
 
+	commentBody, err := planFormatter.FormatWithin(multiPlanData, budget)
+	if err != nil {
+		return "", fmt.Errorf("error formatting plans: %w", err)
+	}
 	return commentBody, nil
 }
 
-func handleGitLabComment(ctx context.Context, commentBody string, gitlabClient GitLabCommenter) error {
+// resolveNamespace picks the namespace from the -namespace flag, then
+// COMMENTER_NAMESPACE, then CI_JOB_NAME, then the set of plan files, escaped
+// to keep slashes and spaces out of the markers.
+func resolveNamespace(flagValue, envValue, jobName string, planFiles []string) string {
+	return url.PathEscape(cmp.Or(flagValue, envValue, jobName, planSet(planFiles)))
+}
+
+// planSet names the set of plan files, independently of their order, as the
+// default namespace outside of CI jobs.
+func planSet(planFiles []string) string {
+	names := make([]string, len(planFiles))
+	for i, file := range planFiles {
+		names[i] = filepath.ToSlash(filepath.Clean(file))
+	}
+	slices.Sort(names)
+	return strings.Join(slices.Compact(names), ",")
+}
+
+// validateNoteStyle checks the note visibility and style. GitLab has no
+// internal discussions, so discussions are always public: the comment can
+// only be posted as one, and inline or destroy discussions only opened, when
+// the comment is public too.
+func validateNoteStyle(visibility, style string, opts options) error {
+	if visibility != gitlab.VisibilityInternal && visibility != gitlab.VisibilityPublic {
+		return fmt.Errorf("invalid note visibility %q: must be %q or %q", visibility, gitlab.VisibilityInternal, gitlab.VisibilityPublic)
+	}
+	if style != gitlab.StyleNote && style != gitlab.StyleDiscussion {
+		return fmt.Errorf("invalid note style %q: must be %q or %q", style, gitlab.StyleNote, gitlab.StyleDiscussion)
+	}
+	if style == gitlab.StyleDiscussion && visibility == gitlab.VisibilityInternal {
+		return fmt.Errorf("note style %q requires note visibility %q: GitLab discussions cannot be internal", gitlab.StyleDiscussion, gitlab.VisibilityPublic)
+	}
+	if visibility == gitlab.VisibilityInternal {
+		if opts.inlineDiscussions {
+			return fmt.Errorf("inline discussions require note visibility %q: GitLab discussions cannot be internal", gitlab.VisibilityPublic)
+		}
+		if opts.blockDestroy {
+			return fmt.Errorf("the destroy discussion requires note visibility %q: GitLab discussions cannot be internal", gitlab.VisibilityPublic)
+		}
+	}
+	return nil
+}
+
+// reportURL returns where the full report can be found: FULL_REPORT_URL, or
+// else the job artifact of the most complete report written by this run.
+func reportURL(cfg *config.Config, outputs outputList) string {
+	if cfg.FullReportURL != "" {
+		return cfg.FullReportURL
+	}
+	if cfg.CIJobURL == "" {
+		return ""
+	}
+	for _, format := range []string{output.FormatHTML, output.FormatMarkdown, output.FormatText, output.FormatJSON} {
+		for _, target := range outputs {
+			if target.Format == format && target.Path != "-" && !filepath.IsAbs(target.Path) {
+				return cfg.CIJobURL + "/artifacts/file/" + filepath.ToSlash(filepath.Clean(target.Path))
+			}
+		}
+	}
+	return ""
+}
+
+func writeOutput(target output.Target, commentBody string, multiPlanData *terraform.MultiPlanData, opts options) error {
+	if target.Format == output.FormatTerraform {
+		return writeTerraformReports(target, multiPlanData)
+	}
+
+	var content string
+	var err error
+	switch target.Format {
+	case output.FormatMarkdown:
+		content = commentBody
+	case output.FormatJSON:
+		content, err = formatter.FormatJSON(multiPlanData)
+	case output.FormatText:
+		content, err = formatter.FormatText(multiPlanData)
+	case output.FormatHTML:
+		content, err = formatter.FormatHTML(multiPlanData)
+	case output.FormatCodeQuality:
+		content, err = formatter.FormatCodeQuality(multiPlanData, opts.allowedDestroys)
+	case output.FormatJUnit:
+		content, err = formatter.FormatJUnit(multiPlanData, opts.allowedDestroys)
+	}
+	if err != nil {
+		return fmt.Errorf("error formatting %s output: %w", target.Format, err)
+	}
+
+	if err := output.Write(content, target.Path); err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	output.PrintSuccess(target)
+	return nil
+}
+
+// writeTerraformReports writes one GitLab Terraform report per plan. With a
+// single plan the path is used as given. Plans whose reports would land in the
+// same file are refused before anything is written.
+func writeTerraformReports(target output.Target, multiPlanData *terraform.MultiPlanData) error {
+	planTargets := make([]output.Target, len(multiPlanData.Plans))
+	written := make(map[string]string, len(multiPlanData.Plans))
+	for i, plan := range multiPlanData.Plans {
+		planTargets[i] = target
+		if len(multiPlanData.Plans) > 1 {
+			planTargets[i] = target.ForPlan(plan.Name)
+		}
+		path := planTargets[i].Path
+		if other, ok := written[path]; ok && path != "-" {
+			return fmt.Errorf("terraform reports of plans %s and %s would both be written to %s", other, plan.Name, path)
+		}
+		written[path] = plan.Name
+	}
+
+	for i, plan := range multiPlanData.Plans {
+		content, err := formatter.FormatTerraformReport(plan.Data)
+		if err != nil {
+			return fmt.Errorf("error formatting terraform report for %s: %w", plan.Name, err)
+		}
+		if err := output.Write(content, planTargets[i].Path); err != nil {
+			return fmt.Errorf("error writing output: %w", err)
+		}
+		output.PrintSuccess(planTargets[i])
+	}
+	return nil
+}
+
+// notePartOverhead is reserved in every part for the markers and the part
+// heading added around the split comment.
+const notePartOverhead = 200
+
+// findPlanNotes returns the notes of the previous run, from the note cache
+// when it still points at them, and deletes duplicate notes left by runs that
+// missed the existing comment.
+func findPlanNotes(ctx context.Context, gitlabClient GitLabCommenter, opts options) ([]*types.MRNote, error) {
 	if err := gitlabClient.ValidateAccess(ctx); err != nil {
-		return fmt.Errorf("error validating GitLab access: %w", err)
+		return nil, fmt.Errorf("error validating GitLab access: %w", err)
+	}
+
+	if opts.noteCache != "" {
+		existingNotes, err := cachedPlanNotes(ctx, gitlabClient, opts.noteCache, opts.mergeRequest)
+		if err != nil {
+			return nil, err
+		}
+		if existingNotes != nil {
+			slog.Info("found existing notes from note cache", "notes", len(existingNotes))
+			return existingNotes, nil
+		}
 	}
 
+	found, err := gitlabClient.FindPlanNotes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding existing plan notes: %w", err)
+	}
+
+	var existingNotes []*types.MRNote
+	for _, note := range found {
+		if !note.Duplicate {
+			existingNotes = append(existingNotes, note)
+			continue
+		}
+		if err := gitlabClient.DeleteNote(ctx, note.ID); err != nil {
+			return nil, fmt.Errorf("error deleting duplicate note: %w", err)
+		}
+		slog.Info("deleted duplicate note", "note_id", note.ID)
+	}
+	return existingNotes, nil
+}
+
+// postComment posts the comment and returns the IDs of its notes.
+func postComment(ctx context.Context, commentBody string, gitlabClient GitLabCommenter, existingNotes []*types.MRNote, maxNoteLength int, namespace string) ([]int64, error) {
 	slog.Info("comment body ready", "length", len(commentBody))
-	existingNote, err := gitlabClient.FindExistingPlanNote(ctx)
+	parts := formatter.SplitComment(commentBody, maxNoteLength-notePartOverhead)
+	if len(parts) > 1 {
+		slog.Info("comment split across notes", "parts", len(parts))
+	}
+	return updateOrCreateNotes(ctx, gitlabClient, existingNotes, parts, namespace)
+}
+
+// updateOrCreateNotes posts the parts as a series of notes, reusing the notes
+// of the previous run in order and deleting those no longer needed. It
+// returns the IDs of the notes in the series.
+func updateOrCreateNotes(ctx context.Context, gitlabClient GitLabCommenter, existingNotes []*types.MRNote, parts []string, namespace string) ([]int64, error) {
+	existingNotes, err := replaceRestyledNotes(ctx, gitlabClient, existingNotes)
 	if err != nil {
-		return fmt.Errorf("error finding existing plan note: %w", err)
+		return nil, err
+	}
+
+	noteIDs := make([]int64, 0, len(parts))
+	for i, part := range parts {
+		existingNote := &types.MRNote{Exists: false}
+		if i < len(existingNotes) {
+			existingNote = existingNotes[i]
+		}
+		noteID, err := updateOrCreateNote(ctx, gitlabClient, existingNote, partBody(part, i+1, len(parts)), namespace, i+1, len(parts))
+		if err != nil {
+			return nil, err
+		}
+		noteIDs = append(noteIDs, noteID)
+	}
+
+	for _, surplus := range existingNotes[min(len(parts), len(existingNotes)):] {
+		if err := gitlabClient.DeleteNote(ctx, surplus.ID); err != nil {
+			return nil, fmt.Errorf("error deleting note: %w", err)
+		}
+		slog.Info("deleted surplus note", "note_id", surplus.ID)
+	}
+	return noteIDs, nil
+}
+
+// replaceRestyledNotes deletes the notes of the previous run when any of them
+// was posted with another visibility or style, so that the whole series is
+// posted again in order with the configured one.
+func replaceRestyledNotes(ctx context.Context, gitlabClient GitLabCommenter, existingNotes []*types.MRNote) ([]*types.MRNote, error) {
+	if !slices.ContainsFunc(existingNotes, func(note *types.MRNote) bool { return note.StyleChanged }) {
+		return existingNotes, nil
+	}
+	for _, note := range existingNotes {
+		if err := gitlabClient.DeleteNote(ctx, note.ID); err != nil {
+			return nil, fmt.Errorf("error deleting note: %w", err)
+		}
+		slog.Info("deleted note posted with another visibility or style", "note_id", note.ID)
+	}
+	return nil, nil
+}
+
+// partBody adds a visible part heading to every part after the first.
+func partBody(part string, index, total int) string {
+	if index == 1 {
+		return part
 	}
-	return updateOrCreateNote(ctx, gitlabClient, existingNote, commentBody)
+	return fmt.Sprintf("_Terraform Plan Summary, part %d/%d_\n\n%s", index, total, part)
 }
 
-func updateOrCreateNote(ctx context.Context, gitlabClient GitLabCommenter, existingNote *types.MRNote, commentBody string) error {
-	markedBody := withMarker(commentBody)
+func updateOrCreateNote(ctx context.Context, gitlabClient GitLabCommenter, existingNote *types.MRNote, commentBody, namespace string, part, parts int) (int64, error) {
+	markedBody := withMarker(commentBody, namespace, part, parts)
 	if existingNote.Exists {
 		slog.Info("found existing note", "note_id", existingNote.ID)
-		strippedExisting := strings.TrimPrefix(existingNote.Body, constants.NoteMarker+"\n")
-		if !gitlabClient.ShouldUpdateNote(strippedExisting, commentBody) {
+		if !gitlabClient.ShouldUpdateNote(stripMarkers(existingNote.Body, namespace), commentBody) {
 			slog.Info("note up to date, skipping update")
-			return nil
+			return existingNote.ID, nil
 		}
 		if err := gitlabClient.UpdateNote(ctx, existingNote.ID, markedBody); err != nil {
-			return fmt.Errorf("error updating note: %w", err)
+			return 0, fmt.Errorf("error updating note: %w", err)
 		}
 		slog.Info("updated existing note", "note_id", existingNote.ID)
-		return nil
+		return existingNote.ID, nil
 	}
 	slog.Info("creating new note")
-	if err := gitlabClient.CreateNote(ctx, markedBody); err != nil {
-		return fmt.Errorf("error creating note: %w", err)
+	noteID, err := gitlabClient.CreateNote(ctx, markedBody)
+	if err != nil {
+		return 0, fmt.Errorf("error creating note: %w", err)
 	}
-	slog.Info("created new note")
-	return nil
+	slog.Info("created new note", "note_id", noteID)
+	return noteID, nil
 }
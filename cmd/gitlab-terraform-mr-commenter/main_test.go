@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/output"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestValidateNoteStyle(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		style      string
+		opts       options
+		wantErr    bool
+	}{
+		{name: "internal note", visibility: "internal", style: "note"},
+		{name: "public note", visibility: "public", style: "note"},
+		{name: "public discussion", visibility: "public", style: "discussion"},
+		{name: "internal discussion", visibility: "internal", style: "discussion", wantErr: true},
+		{name: "unknown visibility", visibility: "secret", style: "note", wantErr: true},
+		{name: "unknown style", visibility: "public", style: "thread", wantErr: true},
+		{name: "inline discussions on internal notes", visibility: "internal", style: "note", opts: options{inlineDiscussions: true}, wantErr: true},
+		{name: "destroy discussion on internal notes", visibility: "internal", style: "note", opts: options{blockDestroy: true}, wantErr: true},
+		{name: "discussions on public notes", visibility: "public", style: "note", opts: options{inlineDiscussions: true, blockDestroy: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNoteStyle(tt.visibility, tt.style, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateNoteStyle() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplaceRestyledNotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []*types.MRNote
+		wantNotes int
+		wantCalls []string
+	}{
+		{
+			name:      "same style",
+			existing:  []*types.MRNote{{ID: 1, Part: 1}, {ID: 2, Part: 2}},
+			wantNotes: 2,
+		},
+		{
+			name:      "one part restyled",
+			existing:  []*types.MRNote{{ID: 1, Part: 1}, {ID: 2, Part: 2, StyleChanged: true}},
+			wantCalls: []string{"delete note 1", "delete note 2"},
+		},
+		{
+			name: "no notes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeGitLab{}
+			got, err := replaceRestyledNotes(context.Background(), client, tt.existing)
+			if err != nil {
+				t.Fatalf("replaceRestyledNotes() error = %v", err)
+			}
+			if len(got) != tt.wantNotes {
+				t.Errorf("replaceRestyledNotes() kept %d notes, want %d", len(got), tt.wantNotes)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveNamespace(t *testing.T) {
+	planFiles := []string{"./prod/plan.json", "network/plan.json"}
+
+	tests := []struct {
+		name      string
+		flagValue string
+		envValue  string
+		jobName   string
+		want      string
+	}{
+		{name: "flag", flagValue: "app", envValue: "env", jobName: "plan", want: "app"},
+		{name: "environment", envValue: "env", jobName: "plan", want: "env"},
+		{name: "job name", jobName: "plan: [prod]", want: "plan:%20%5Bprod%5D"},
+		{name: "plan files", want: "network%2Fplan.json%2Cprod%2Fplan.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveNamespace(tt.flagValue, tt.envValue, tt.jobName, planFiles); got != tt.want {
+				t.Errorf("resolveNamespace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanSet(t *testing.T) {
+	got := planSet([]string{"prod/plan.json", "./network/plan.json", "prod/../prod/plan.json"})
+	if want := "network/plan.json,prod/plan.json"; got != want {
+		t.Errorf("planSet() = %q, want %q", got, want)
+	}
+}
+
+func TestStripMarkers(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "single note",
+			body: withMarker("comment", "prod", 1, 1),
+			want: "comment",
+		},
+		{
+			name: "part of a series",
+			body: withMarker("comment", "prod", 2, 3),
+			want: "comment",
+		},
+		{
+			name: "legacy marker",
+			body: "<!-- gitlab-terraform-mr-commenter -->\ncomment",
+			want: "<!-- gitlab-terraform-mr-commenter -->\ncomment",
+		},
+		{
+			name: "other namespace",
+			body: withMarker("comment", "other", 1, 1),
+			want: withMarker("comment", "other", 1, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripMarkers(tt.body, "prod"); got != tt.want {
+				t.Errorf("stripMarkers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLegacyNote(t *testing.T) {
+	client := &fakeGitLab{}
+	legacy := &types.MRNote{ID: 7, Exists: true, Body: "<!-- gitlab-terraform-mr-commenter -->\ncomment"}
+	noteIDs, err := updateOrCreateNotes(context.Background(), client, []*types.MRNote{legacy}, []string{"comment"}, "prod")
+	if err != nil {
+		t.Fatalf("updateOrCreateNotes() error = %v", err)
+	}
+	if diff := cmp.Diff([]int64{7}, noteIDs); diff != "" {
+		t.Errorf("updateOrCreateNotes() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"update note 7"}, client.calls); diff != "" {
+		t.Errorf("legacy note not taken over (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteTerraformReports(t *testing.T) {
+	plans := func(names ...string) *terraform.MultiPlanData {
+		multiPlanData := &terraform.MultiPlanData{}
+		for _, name := range names {
+			multiPlanData.Plans = append(multiPlanData.Plans, &terraform.PlanWithIdentifier{Name: name, Data: &terraform.PlanData{}})
+		}
+		return multiPlanData
+	}
+
+	tests := []struct {
+		name      string
+		plans     *terraform.MultiPlanData
+		wantFiles []string
+		wantErr   bool
+	}{
+		{
+			name:      "one file per plan",
+			plans:     plans("prod/plan", "staging/plan"),
+			wantFiles: []string{"tfplan.prod-plan.json", "tfplan.staging-plan.json"},
+		},
+		{
+			name:    "colliding file names",
+			plans:   plans("prod/plan", "prod-plan"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := output.Target{Format: output.FormatTerraform, Path: filepath.Join(dir, "tfplan.json")}
+			err := writeTerraformReports(target, tt.plans)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeTerraformReports() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, file := range tt.wantFiles {
+				if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+					t.Errorf("report %s not written: %v", file, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// noteCache records the notes posted for a merge request, so that the next
+// run can fetch them directly instead of listing every note. The merge
+// request is recorded too, as CI caches are often shared between branches.
+type noteCache struct {
+	MergeRequest string  `json:"merge_request"`
+	NoteIDs      []int64 `json:"note_ids"`
+}
+
+// cachedPlanNotes returns the notes recorded in the cache for the merge
+// request, or nil when there is no usable cache or the notes are gone.
+func cachedPlanNotes(ctx context.Context, gitlabClient GitLabCommenter, path, mergeRequest string) ([]*types.MRNote, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Warn("could not read note cache", "path", path, "error", err)
+		return nil, nil
+	}
+	var cache noteCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		slog.Warn("ignoring invalid note cache", "path", path, "error", err)
+		return nil, nil
+	}
+	if cache.MergeRequest != mergeRequest || len(cache.NoteIDs) == 0 {
+		return nil, nil
+	}
+
+	existingNotes, err := gitlabClient.GetPlanNotes(ctx, cache.NoteIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cached plan notes: %w", err)
+	}
+	return existingNotes, nil
+}
+
+func saveNoteCache(path, mergeRequest string, noteIDs []int64) error {
+	content, err := json.Marshal(noteCache{MergeRequest: mergeRequest, NoteIDs: noteIDs})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o644)
+}
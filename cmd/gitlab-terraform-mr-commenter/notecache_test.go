@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestCachedPlanNotes(t *testing.T) {
+	notes := []*types.MRNote{{ID: 7, Exists: true, Part: 1, Parts: 1}}
+
+	tests := []struct {
+		name      string
+		content   string
+		wantIDs   []int64
+		wantCalls []string
+	}{
+		{
+			name:      "cached notes",
+			content:   `{"merge_request":"1!2","note_ids":[7]}`,
+			wantIDs:   []int64{7},
+			wantCalls: []string{"get notes [7]"},
+		},
+		{
+			name:    "another merge request",
+			content: `{"merge_request":"1!3","note_ids":[7]}`,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{"merge_request":`,
+		},
+		{
+			name:    "no notes",
+			content: `{"merge_request":"1!2","note_ids":[]}`,
+		},
+		{
+			name:      "deleted note",
+			content:   `{"merge_request":"1!2","note_ids":[8]}`,
+			wantCalls: []string{"get notes [8]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "notes.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			client := &fakeGitLab{notes: notes}
+			got, err := cachedPlanNotes(context.Background(), client, path, "1!2")
+			if err != nil {
+				t.Fatalf("cachedPlanNotes() error = %v", err)
+			}
+			var ids []int64
+			for _, note := range got {
+				ids = append(ids, note.ID)
+			}
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("cachedPlanNotes() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCachedPlanNotesWithoutFile(t *testing.T) {
+	client := &fakeGitLab{}
+	got, err := cachedPlanNotes(context.Background(), client, filepath.Join(t.TempDir(), "missing.json"), "1!2")
+	if err != nil || got != nil {
+		t.Errorf("cachedPlanNotes() = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestSaveNoteCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "notes.json")
+	if err := saveNoteCache(path, "1!2", []int64{7, 9}); err != nil {
+		t.Fatalf("saveNoteCache() error = %v", err)
+	}
+
+	client := &fakeGitLab{notes: []*types.MRNote{{ID: 7}, {ID: 9}}}
+	got, err := cachedPlanNotes(context.Background(), client, path, "1!2")
+	if err != nil {
+		t.Fatalf("cachedPlanNotes() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 7 || got[1].ID != 9 {
+		t.Errorf("cachedPlanNotes() after saveNoteCache() = %v, want notes 7 and 9", got)
+	}
+}
+
+func TestFindPlanNotes(t *testing.T) {
+	listed := []*types.MRNote{
+		{ID: 30, Exists: true, Part: 1, Parts: 1},
+		{ID: 20, Exists: true, Part: 1, Parts: 1, Duplicate: true},
+		{ID: 10, Exists: true, Part: 1, Parts: 1, Duplicate: true},
+	}
+
+	tests := []struct {
+		name      string
+		cache     string
+		wantIDs   []int64
+		wantCalls []string
+	}{
+		{
+			name:      "deletes duplicates",
+			wantIDs:   []int64{30},
+			wantCalls: []string{"list notes", "delete note 20", "delete note 10"},
+		},
+		{
+			name:      "uses the cache",
+			cache:     `{"merge_request":"1!2","note_ids":[30]}`,
+			wantIDs:   []int64{30},
+			wantCalls: []string{"get notes [30]"},
+		},
+		{
+			name:      "lists notes when the cached ones are gone",
+			cache:     `{"merge_request":"1!2","note_ids":[40]}`,
+			wantIDs:   []int64{30},
+			wantCalls: []string{"get notes [40]", "list notes", "delete note 20", "delete note 10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options{mergeRequest: "1!2"}
+			if tt.cache != "" {
+				opts.noteCache = filepath.Join(t.TempDir(), "notes.json")
+				if err := os.WriteFile(opts.noteCache, []byte(tt.cache), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := &fakeGitLab{notes: listed}
+			got, err := findPlanNotes(context.Background(), client, opts)
+			if err != nil {
+				t.Fatalf("findPlanNotes() error = %v", err)
+			}
+			var ids []int64
+			for _, note := range got {
+				ids = append(ids, note.ID)
+			}
+			if diff := cmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("findPlanNotes() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestUpdateOrCreateNotes(t *testing.T) {
+	series := func(parts ...string) []*types.MRNote {
+		var notes []*types.MRNote
+		for i, part := range parts {
+			notes = append(notes, &types.MRNote{
+				ID:     int64(i + 1),
+				Exists: true,
+				Body:   withMarker(partBody(part, i+1, len(parts)), "prod", i+1, len(parts)),
+				Part:   i + 1,
+				Parts:  len(parts),
+			})
+		}
+		return notes
+	}
+
+	tests := []struct {
+		name        string
+		existing    []*types.MRNote
+		parts       []string
+		wantNoteIDs []int64
+		wantCalls   []string
+	}{
+		{
+			name:        "posts a new series",
+			parts:       []string{"a", "b"},
+			wantNoteIDs: []int64{101, 102},
+			wantCalls:   []string{"create note 101", "create note 102"},
+		},
+		{
+			name:        "leaves an unchanged series alone",
+			existing:    series("a", "b"),
+			parts:       []string{"a", "b"},
+			wantNoteIDs: []int64{1, 2},
+		},
+		{
+			name:        "updates the series in place",
+			existing:    series("a", "b", "c"),
+			parts:       []string{"a", "x", "y"},
+			wantNoteIDs: []int64{1, 2, 3},
+			wantCalls:   []string{"update note 2", "update note 3"},
+		},
+		{
+			name:        "deletes surplus parts when the comment shrinks",
+			existing:    series("a", "b", "c"),
+			parts:       []string{"x"},
+			wantNoteIDs: []int64{1},
+			wantCalls:   []string{"update note 1", "delete note 2", "delete note 3"},
+		},
+		{
+			name:        "adds parts when the comment grows",
+			existing:    series("a"),
+			parts:       []string{"a", "b"},
+			wantNoteIDs: []int64{1, 101},
+			wantCalls:   []string{"create note 101"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeGitLab{}
+			noteIDs, err := updateOrCreateNotes(context.Background(), client, tt.existing, tt.parts, "prod")
+			if err != nil {
+				t.Fatalf("updateOrCreateNotes() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantNoteIDs, noteIDs); diff != "" {
+				t.Errorf("note IDs mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantCalls, client.calls); diff != "" {
+				t.Errorf("calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/constants"
+	"gitlab-terraform-mr-commenter/internal/formatter"
+	"gitlab-terraform-mr-commenter/internal/output"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+var reportExtensions = map[string]string{
+	output.FormatMarkdown: "md",
+	output.FormatHTML:     "html",
+	output.FormatJSON:     "json",
+}
+
+// uploadReport uploads the full report and returns its URL together with the
+// hidden marker that records it in the note. A report identical to the one
+// recorded in the existing notes is not uploaded again.
+func uploadReport(ctx context.Context, gitlabClient GitLabCommenter, planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, format string, existingNotes []*types.MRNote) (string, string, error) {
+	content, err := reportContent(planFormatter, multiPlanData, format)
+	if err != nil {
+		return "", "", fmt.Errorf("error formatting report: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	if url, ok := uploadedReport(existingNotes, hash); ok {
+		slog.Info("report unchanged, reusing upload", "url", url)
+		return url, reportMarker(hash, url), nil
+	}
+
+	url, err := gitlabClient.UploadFile(ctx, "terraform-plan-report."+reportExtensions[format], []byte(content))
+	if err != nil {
+		return "", "", fmt.Errorf("error uploading report: %w", err)
+	}
+	slog.Info("uploaded report", "url", url)
+	return url, reportMarker(hash, url), nil
+}
+
+// reportContent renders the full report. The report is the full report
+// itself, so it carries no link to one: a link to this job's artifacts
+// would also change the report, and its hash, on every pipeline.
+func reportContent(planFormatter *formatter.Formatter, multiPlanData *terraform.MultiPlanData, format string) (string, error) {
+	report := *multiPlanData
+	report.Display.ReportURL = ""
+	switch format {
+	case output.FormatMarkdown:
+		return planFormatter.Format(&report)
+	case output.FormatHTML:
+		return formatter.FormatHTML(&report)
+	case output.FormatJSON:
+		return formatter.FormatJSON(&report)
+	}
+	return "", fmt.Errorf("unsupported report format %q", format)
+}
+
+func reportMarker(hash, url string) string {
+	return fmt.Sprintf(constants.ReportMarkerFormat, hash, url)
+}
+
+// uploadedReport returns the URL recorded in the existing notes for a report
+// with the given hash.
+func uploadedReport(existingNotes []*types.MRNote, hash string) (string, bool) {
+	for _, note := range existingNotes {
+		for _, line := range strings.Split(note.Body, "\n") {
+			var recordedHash, url string
+			if _, err := fmt.Sscanf(line, constants.ReportMarkerFormat, &recordedHash, &url); err == nil && recordedHash == hash {
+				return url, true
+			}
+		}
+	}
+	return "", false
+}
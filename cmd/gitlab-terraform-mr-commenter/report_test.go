@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/formatter"
+	"gitlab-terraform-mr-commenter/internal/output"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestUploadReportAcrossPipelines(t *testing.T) {
+	planFormatter, err := formatter.New("")
+	if err != nil {
+		t.Fatalf("formatter.New() error = %v", err)
+	}
+
+	client := &fakeGitLab{}
+	var existingNotes []*types.MRNote
+	for _, jobURL := range []string{"https://gitlab.example.com/job/1", "https://gitlab.example.com/job/2"} {
+		plans := testPlans("aws_s3_bucket.logs")
+		plans.Display.ReportURL = jobURL + "/artifacts/file/report.html"
+
+		content, err := reportContent(planFormatter, plans, output.FormatMarkdown)
+		if err != nil {
+			t.Fatalf("reportContent() error = %v", err)
+		}
+		if strings.Contains(content, jobURL) {
+			t.Errorf("report links to the job artifacts of %s", jobURL)
+		}
+
+		url, marker, err := uploadReport(context.Background(), client, planFormatter, plans, output.FormatMarkdown, existingNotes)
+		if err != nil {
+			t.Fatalf("uploadReport() error = %v", err)
+		}
+		if url != "/uploads/terraform-plan-report.md" {
+			t.Errorf("uploadReport() url = %q", url)
+		}
+		existingNotes = []*types.MRNote{{ID: 1, Exists: true, Body: "comment\n" + marker}}
+	}
+
+	if diff := cmp.Diff([]string{"upload terraform-plan-report.md"}, client.calls); diff != "" {
+		t.Errorf("unchanged report uploaded again (-want +got):\n%s", diff)
+	}
+}
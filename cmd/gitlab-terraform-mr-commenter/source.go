@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/source"
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// attachSources records where each changed resource is declared, reading the
+// configuration from the source directory or, by default, from the directory
+// of each plan file. Source links are optional, so configuration that cannot
+// be read only leaves the resources it declares unlinked.
+func attachSources(multiPlanData *terraform.MultiPlanData, opts options) error {
+	root, err := filepath.Abs(opts.projectDir)
+	if err != nil {
+		return fmt.Errorf("error resolving project directory: %w", err)
+	}
+
+	indexes := make(map[string]source.Index)
+	for _, plan := range multiPlanData.Plans {
+		dir := opts.sourceDir
+		if dir == "" {
+			dir = filepath.Dir(plan.Path)
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("error resolving source directory: %w", err)
+		}
+
+		index, ok := indexes[dir]
+		if !ok {
+			index, err = source.Load(dir, root)
+			if err != nil {
+				slog.Warn("skipping unreadable Terraform configuration, resources it declares are not linked", "dir", dir, "error", err)
+			}
+			indexes[dir] = index
+		}
+		source.Attach(plan.Data, index)
+	}
+	return nil
+}
+
+// linkSources links every source location into the merge request diff or to
+// the file at the merge request's head commit.
+func linkSources(multiPlanData *terraform.MultiPlanData, changes *types.MRChanges, opts options) {
+	projectURL := opts.projectURL
+	if projectURL == "" {
+		projectURL, _, _ = strings.Cut(changes.WebURL, "/-/merge_requests/")
+	}
+	source.NewLinker(projectURL, changes.HeadSHA, changes.WebURL, changes.Files).LinkAll(multiPlanData)
+}
+
+func hasSources(multiPlanData *terraform.MultiPlanData) bool {
+	for _, plan := range multiPlanData.Plans {
+		for _, resources := range [][]*terraform.ResourceData{
+			plan.Data.CreatedResources,
+			plan.Data.UpdatedResources,
+			plan.Data.RecreatedResources,
+			plan.Data.DeletedResources,
+		} {
+			for _, resource := range resources {
+				if resource.Source != nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
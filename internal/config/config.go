@@ -5,10 +5,28 @@ import (
 )
 
 type Config struct {
-	GitlabToken    string `envconfig:"GITLAB_TOKEN" required:"true"`
-	GitlabURL      string `envconfig:"GITLAB_URL" default:"https://gitlab.com"`
-	ProjectID      string `envconfig:"GITLAB_PROJECT_ID" required:"true"`
-	MergeRequestID int64  `envconfig:"GITLAB_MR_ID" required:"true"`
+	GitlabToken       string   `envconfig:"GITLAB_TOKEN" required:"true"`
+	GitlabURL         string   `envconfig:"GITLAB_URL" default:"https://gitlab.com"`
+	ProjectID         string   `envconfig:"GITLAB_PROJECT_ID" required:"true"`
+	MergeRequestID    int64    `envconfig:"GITLAB_MR_ID" required:"true"`
+	TemplatePath      string   `envconfig:"TEMPLATE_PATH"`
+	AllowedDestroys   []string `envconfig:"ALLOWED_DESTROYS"`
+	MaxNoteLength     int      `envconfig:"MAX_NOTE_LENGTH" default:"1000000"`
+	CommentBudget     int      `envconfig:"COMMENT_BUDGET"`
+	FullReportURL     string   `envconfig:"FULL_REPORT_URL"`
+	CIJobURL          string   `envconfig:"CI_JOB_URL"`
+	UploadReport      string   `envconfig:"UPLOAD_REPORT"`
+	SourceDir         string   `envconfig:"TERRAFORM_SOURCE_DIR"`
+	CIProjectDir      string   `envconfig:"CI_PROJECT_DIR"`
+	CIProjectURL      string   `envconfig:"CI_PROJECT_URL"`
+	CICommitSHA       string   `envconfig:"CI_COMMIT_SHA"`
+	InlineDiscussions bool     `envconfig:"INLINE_DISCUSSIONS"`
+	BlockDestroy      bool     `envconfig:"BLOCK_DESTROY"`
+	NoteVisibility    string   `envconfig:"NOTE_VISIBILITY" default:"internal"`
+	NoteStyle         string   `envconfig:"NOTE_STYLE" default:"note"`
+	NoteCacheFile     string   `envconfig:"NOTE_CACHE_FILE"`
+	Namespace         string   `envconfig:"COMMENTER_NAMESPACE"`
+	CIJobName         string   `envconfig:"CI_JOB_NAME"`
 }
 
 func Load() (*Config, error) {
@@ -1,3 +1,29 @@
 package constants
 
+// NoteMarker starts the notes posted before markers were namespaced.
 const NoteMarker = "<!-- gitlab-terraform-mr-commenter -->"
+
+// NamespacedNoteMarkerFormat starts every note, with the namespace of the
+// commenter that posted it, so that several commenters can share a merge
+// request. Namespaces are path-escaped to hold no spaces or slashes.
+const NamespacedNoteMarkerFormat = "<!-- gitlab-terraform-mr-commenter namespace %s -->"
+
+// PartMarkerFormat follows the note marker in notes that are part of a series,
+// with the part number and the number of parts.
+const PartMarkerFormat = "<!-- gitlab-terraform-mr-commenter part %d/%d -->"
+
+// MaxNoteLength is the largest note body GitLab accepts.
+const MaxNoteLength = 1000000
+
+// ReportMarkerFormat records the hash and URL of the uploaded full report in
+// the note, so that an unchanged report is not uploaded again.
+const ReportMarkerFormat = "<!-- gitlab-terraform-mr-commenter report %s %s -->"
+
+// DiscussionMarkerFormat starts the first note of every discussion opened by
+// the commenter, with a key telling what the discussion is about, prefixed by
+// the commenter's namespace and a slash.
+const DiscussionMarkerFormat = "<!-- gitlab-terraform-mr-commenter discussion %s -->"
+
+// DestroySetMarkerFormat records, in the discussion asking for destructive
+// changes to be acknowledged, a hash of the resources it was opened for.
+const DestroySetMarkerFormat = "<!-- gitlab-terraform-mr-commenter destroys %s -->"
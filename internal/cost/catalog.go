@@ -0,0 +1,133 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const defaultCurrency = "USD"
+
+// Catalog is a user-supplied price list keyed by Terraform resource type.
+// For every resource type the first rule whose match attributes agree with
+// the resource's values determines its monthly price.
+type Catalog struct {
+	Currency  string            `json:"currency" yaml:"currency"`
+	Resources map[string][]Rule `json:"resources" yaml:"resources"`
+}
+
+// Rule prices a resource as (Monthly + sum(attribute * PerUnit)) * Multiplier.
+// Attribute names may use dotted paths to reach nested values, for example
+// "scaling_config.0.desired_size".
+type Rule struct {
+	Match      map[string]string  `json:"match" yaml:"match"`
+	Monthly    float64            `json:"monthly" yaml:"monthly"`
+	PerUnit    map[string]float64 `json:"per_unit" yaml:"per_unit"`
+	Multiplier string             `json:"multiplier" yaml:"multiplier"`
+}
+
+func LoadCatalog(filename string) (*Catalog, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price catalog %s: %w", filename, err)
+	}
+
+	var catalog Catalog
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		err = json.Unmarshal(data, &catalog)
+	default:
+		err = yaml.Unmarshal(data, &catalog)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse price catalog %s: %w", filename, err)
+	}
+
+	if catalog.Currency == "" {
+		catalog.Currency = defaultCurrency
+	}
+
+	return &catalog, nil
+}
+
+// Price returns the monthly price of a resource of the given type with the
+// given attribute values. The second return value is false when the catalog
+// has no rule matching the resource.
+func (c *Catalog) Price(resourceType string, attrs map[string]interface{}) (float64, bool) {
+	for _, rule := range c.Resources[resourceType] {
+		if !rule.matches(attrs) {
+			continue
+		}
+
+		price := rule.Monthly
+		for attr, unitPrice := range rule.PerUnit {
+			if quantity, ok := lookupNumber(attrs, attr); ok {
+				price += quantity * unitPrice
+			}
+		}
+		if rule.Multiplier != "" {
+			if multiplier, ok := lookupNumber(attrs, rule.Multiplier); ok {
+				price *= multiplier
+			}
+		}
+
+		return price, true
+	}
+
+	return 0, false
+}
+
+func (r Rule) matches(attrs map[string]interface{}) bool {
+	for attr, want := range r.Match {
+		got, ok := lookup(attrs, attr)
+		if !ok || got == nil || fmt.Sprintf("%v", got) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func lookup(attrs map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = attrs
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func lookupNumber(attrs map[string]interface{}, path string) (float64, bool) {
+	value, ok := lookup(attrs, path)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,91 @@
+package cost
+
+import (
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// Estimate annotates every resource the catalog can price with its monthly
+// cost before and after the change, and sums those into per-plan and overall
+// totals. Resources the catalog does not know are left without an estimate.
+func Estimate(multiPlanData *terraform.MultiPlanData, catalog *Catalog) {
+	for _, plan := range multiPlanData.Plans {
+		var planTotal *terraform.CostDelta
+
+		for _, resources := range [][]*terraform.ResourceData{
+			plan.Data.CreatedResources,
+			plan.Data.UpdatedResources,
+			plan.Data.RecreatedResources,
+			plan.Data.DeletedResources,
+		} {
+			for _, resource := range resources {
+				var resourceTotal *terraform.CostDelta
+				for _, member := range resource.Members() {
+					delta, ok := estimateResource(member, catalog)
+					if !ok {
+						continue
+					}
+					member.Cost = delta
+					resourceTotal = accumulate(resourceTotal, delta)
+				}
+				if resourceTotal == nil {
+					continue
+				}
+				resource.Cost = resourceTotal
+				planTotal = accumulate(planTotal, resourceTotal)
+			}
+		}
+
+		if planTotal != nil {
+			plan.Data.Cost = planTotal
+		}
+	}
+
+	multiPlanData.Cost = sumPlans(multiPlanData.Plans)
+}
+
+func estimateResource(resource *terraform.ResourceData, catalog *Catalog) (*terraform.CostDelta, bool) {
+	beforeAttrs, afterAttrs := terraform.Attributes(resource)
+	before, ok := priceSide(resource.Type, beforeAttrs, catalog)
+	if !ok {
+		return nil, false
+	}
+
+	after, ok := priceSide(resource.Type, afterAttrs, catalog)
+	if !ok {
+		return nil, false
+	}
+
+	return &terraform.CostDelta{
+		Currency: catalog.Currency,
+		Before:   before,
+		After:    after,
+	}, true
+}
+
+// priceSide prices one side of a change. A missing side (the before of a
+// create or the after of a delete) costs nothing.
+func priceSide(resourceType string, attrs map[string]interface{}, catalog *Catalog) (float64, bool) {
+	if len(attrs) == 0 {
+		return 0, true
+	}
+	return catalog.Price(resourceType, attrs)
+}
+
+func accumulate(total, delta *terraform.CostDelta) *terraform.CostDelta {
+	if total == nil {
+		total = &terraform.CostDelta{Currency: delta.Currency}
+	}
+	total.Before += delta.Before
+	total.After += delta.After
+	return total
+}
+
+func sumPlans(plans []*terraform.PlanWithIdentifier) *terraform.CostDelta {
+	var total *terraform.CostDelta
+	for _, plan := range plans {
+		if plan.Data.Cost != nil {
+			total = accumulate(total, plan.Data.Cost)
+		}
+	}
+	return total
+}
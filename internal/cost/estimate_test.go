@@ -0,0 +1,221 @@
+package cost
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+func loadTestCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	catalog, err := LoadCatalog(filepath.Join("testdata", "catalog.yaml"))
+	if err != nil {
+		t.Fatalf("LoadCatalog() error = %v", err)
+	}
+	return catalog
+}
+
+func TestCatalogPrice(t *testing.T) {
+	catalog := loadTestCatalog(t)
+
+	tests := []struct {
+		name         string
+		resourceType string
+		attrs        map[string]interface{}
+		want         float64
+		wantOK       bool
+	}{
+		{
+			name:         "flat_price",
+			resourceType: "aws_instance",
+			attrs:        map[string]interface{}{"instance_type": "t3.medium"},
+			want:         30.37,
+			wantOK:       true,
+		},
+		{
+			name:         "per_unit_price",
+			resourceType: "aws_db_instance",
+			attrs:        map[string]interface{}{"instance_class": "db.t3.micro", "allocated_storage": float64(100)},
+			want:         12.41 + 11.5,
+			wantOK:       true,
+		},
+		{
+			name:         "multiplier_from_nested_path",
+			resourceType: "aws_eks_node_group",
+			attrs: map[string]interface{}{
+				"instance_types": []interface{}{"m5.large"},
+				"scaling_config": []interface{}{map[string]interface{}{"desired_size": float64(3)}},
+			},
+			want:   210.24,
+			wantOK: true,
+		},
+		{
+			name:         "no_matching_rule",
+			resourceType: "aws_instance",
+			attrs:        map[string]interface{}{"instance_type": "m5.24xlarge"},
+			wantOK:       false,
+		},
+		{
+			name:         "unknown_type",
+			resourceType: "aws_s3_bucket",
+			attrs:        map[string]interface{}{"bucket": "logs"},
+			wantOK:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := catalog.Price(tt.resourceType, tt.attrs)
+			if ok != tt.wantOK {
+				t.Fatalf("Price() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("Price() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	catalog := loadTestCatalog(t)
+
+	resized := &terraform.ResourceData{Address: "aws_instance.web", Type: "aws_instance"}
+	terraform.SetAttributes(resized, map[string]interface{}{"instance_type": "t3.micro"}, map[string]interface{}{"instance_type": "t3.medium"})
+	created := &terraform.ResourceData{Address: "aws_instance.worker", Type: "aws_instance"}
+	terraform.SetAttributes(created, map[string]interface{}{}, map[string]interface{}{"instance_type": "t3.micro"})
+	unpriced := &terraform.ResourceData{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket"}
+	terraform.SetAttributes(unpriced, map[string]interface{}{"bucket": "logs"}, map[string]interface{}{})
+
+	multiPlanData := &terraform.MultiPlanData{
+		Plans: []*terraform.PlanWithIdentifier{
+			{
+				Name: "prod",
+				Data: &terraform.PlanData{
+					CreatedResources: []*terraform.ResourceData{created},
+					UpdatedResources: []*terraform.ResourceData{resized},
+					DeletedResources: []*terraform.ResourceData{unpriced},
+				},
+			},
+			{
+				Name: "empty",
+				Data: &terraform.PlanData{},
+			},
+		},
+	}
+
+	Estimate(multiPlanData, catalog)
+
+	if unpriced.Cost != nil {
+		t.Errorf("unpriced resource got cost %+v, want nil", unpriced.Cost)
+	}
+	if created.Cost == nil || created.Cost.Before != 0 || created.Cost.After != 7.59 {
+		t.Errorf("created cost = %+v, want 0 -> 7.59", created.Cost)
+	}
+	if got := len(multiPlanData.Plans[0].Data.CostedResources()); got != 2 {
+		t.Errorf("CostedResources() len = %d, want 2", got)
+	}
+	if multiPlanData.Plans[1].Data.Cost != nil {
+		t.Errorf("empty plan got cost %+v, want nil", multiPlanData.Plans[1].Data.Cost)
+	}
+
+	total := multiPlanData.Cost
+	if total == nil {
+		t.Fatal("expected total cost")
+	}
+	if math.Abs(total.Delta()-(30.37-7.59+7.59)) > 0.001 {
+		t.Errorf("total Delta() = %v, want %v", total.Delta(), 30.37)
+	}
+}
+
+func TestApplyInfracost(t *testing.T) {
+	report, err := LoadInfracost(filepath.Join("testdata", "infracost.json"))
+	if err != nil {
+		t.Fatalf("LoadInfracost() error = %v", err)
+	}
+
+	web := &terraform.ResourceData{Address: "aws_instance.web"}
+	db := &terraform.ResourceData{Address: "aws_db_instance.main"}
+	logs := &terraform.ResourceData{Address: "aws_s3_bucket.logs", Cost: &terraform.CostDelta{Currency: "USD", Before: 2.3}}
+
+	multiPlanData := &terraform.MultiPlanData{
+		Plans: []*terraform.PlanWithIdentifier{
+			{
+				Name: "prod",
+				Path: "environments/prod/prod.json",
+				Data: &terraform.PlanData{
+					CreatedResources: []*terraform.ResourceData{db},
+					UpdatedResources: []*terraform.ResourceData{web},
+					DeletedResources: []*terraform.ResourceData{logs},
+				},
+			},
+			{
+				Name: "unmatched",
+				Path: "elsewhere/unmatched.json",
+				Data: &terraform.PlanData{},
+			},
+		},
+	}
+
+	ApplyInfracost(multiPlanData, []*InfracostReport{report})
+
+	if web.Cost == nil || web.Cost.Before != 7.592 || web.Cost.After != 30.368 {
+		t.Errorf("web cost = %+v, want 7.592 -> 30.368", web.Cost)
+	}
+	if db.Cost == nil || db.Cost.Before != 0 || db.Cost.After != 24.82 {
+		t.Errorf("db cost = %+v, want 0 -> 24.82", db.Cost)
+	}
+	if logs.Cost != nil {
+		t.Errorf("logs cost = %+v, want catalog estimate cleared for null monthly cost", logs.Cost)
+	}
+	if multiPlanData.Plans[1].Data.Cost != nil {
+		t.Errorf("unmatched plan got cost %+v, want nil", multiPlanData.Plans[1].Data.Cost)
+	}
+	if multiPlanData.Cost == nil || math.Abs(multiPlanData.Cost.Delta()-47.596) > 0.001 {
+		t.Errorf("total cost = %+v, want delta 47.596", multiPlanData.Cost)
+	}
+}
+
+func TestProjectMatchesPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		project InfracostProject
+		plan    terraform.PlanWithIdentifier
+		want    bool
+	}{
+		{
+			name:    "by_name",
+			project: InfracostProject{Name: "prod"},
+			plan:    terraform.PlanWithIdentifier{Name: "prod", Path: "plans/prod.json"},
+			want:    true,
+		},
+		{
+			name:    "by_metadata_path",
+			project: InfracostProject{Name: "infra", Metadata: InfracostMetadata{Path: "./plans/prod.json"}},
+			plan:    terraform.PlanWithIdentifier{Name: "prod", Path: "plans/prod.json"},
+			want:    true,
+		},
+		{
+			name:    "by_directory",
+			project: InfracostProject{Name: "plans"},
+			plan:    terraform.PlanWithIdentifier{Name: "plan", Path: "plans/plan.json"},
+			want:    true,
+		},
+		{
+			name:    "different_plan",
+			project: InfracostProject{Name: "staging", Metadata: InfracostMetadata{Path: "staging/staging.json"}},
+			plan:    terraform.PlanWithIdentifier{Name: "prod", Path: "prod/prod.json"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := projectMatchesPlanFile(&tt.project, &tt.plan) || projectMatchesPlanDir(&tt.project, &tt.plan)
+			if got != tt.want {
+				t.Errorf("project %q matches plan %q = %v, want %v", tt.project.Name, tt.plan.Path, got, tt.want)
+			}
+		})
+	}
+}
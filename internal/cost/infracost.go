@@ -0,0 +1,188 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// InfracostReport is the subset of `infracost breakdown --format json` output
+// needed to attach cost changes to plans.
+type InfracostReport struct {
+	Currency string             `json:"currency"`
+	Projects []InfracostProject `json:"projects"`
+}
+
+type InfracostProject struct {
+	Name          string              `json:"name"`
+	Metadata      InfracostMetadata   `json:"metadata"`
+	PastBreakdown *InfracostBreakdown `json:"pastBreakdown"`
+	Breakdown     *InfracostBreakdown `json:"breakdown"`
+}
+
+type InfracostMetadata struct {
+	Path string `json:"path"`
+}
+
+type InfracostBreakdown struct {
+	Resources        []InfracostResource `json:"resources"`
+	TotalMonthlyCost *string             `json:"totalMonthlyCost"`
+}
+
+type InfracostResource struct {
+	Name        string  `json:"name"`
+	MonthlyCost *string `json:"monthlyCost"`
+}
+
+func LoadInfracost(filename string) (*InfracostReport, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open infracost report %s: %w", filename, err)
+	}
+
+	var report InfracostReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse infracost report %s: %w", filename, err)
+	}
+
+	if report.Currency == "" {
+		report.Currency = defaultCurrency
+	}
+
+	return &report, nil
+}
+
+// ApplyInfracost attaches the monthly cost of every Infracost project to the
+// plan it describes. Projects are matched to plans by name or by path; plans
+// without a matching project keep whatever estimate they already had. In a
+// matched plan, resources Infracost does not price lose any catalog estimate,
+// so that rows and total come from the same source.
+func ApplyInfracost(multiPlanData *terraform.MultiPlanData, reports []*InfracostReport) {
+	for _, plan := range multiPlanData.Plans {
+		project, currency := findProject(plan, reports)
+		if project == nil {
+			continue
+		}
+
+		pastCosts := resourceCosts(project.PastBreakdown)
+		costs := resourceCosts(project.Breakdown)
+
+		for _, resources := range [][]*terraform.ResourceData{
+			plan.Data.CreatedResources,
+			plan.Data.UpdatedResources,
+			plan.Data.RecreatedResources,
+			plan.Data.DeletedResources,
+		} {
+			for _, resource := range resources {
+				var resourceTotal *terraform.CostDelta
+				for _, member := range resource.Members() {
+					before, hasBefore := pastCosts[member.Address]
+					after, hasAfter := costs[member.Address]
+					if !hasBefore && !hasAfter {
+						member.Cost = nil
+						continue
+					}
+					member.Cost = &terraform.CostDelta{
+						Currency: currency,
+						Before:   before,
+						After:    after,
+					}
+					resourceTotal = accumulate(resourceTotal, member.Cost)
+				}
+				resource.Cost = resourceTotal
+			}
+		}
+
+		plan.Data.Cost = &terraform.CostDelta{
+			Currency: currency,
+			Before:   totalCost(project.PastBreakdown),
+			After:    totalCost(project.Breakdown),
+		}
+	}
+
+	multiPlanData.Cost = sumPlans(multiPlanData.Plans)
+}
+
+// findProject returns the first project matching the plan, preferring matches
+// on the plan's name or file over matches on the directory containing it, so
+// several plans written next to each other still find their own project.
+func findProject(plan *terraform.PlanWithIdentifier, reports []*InfracostReport) (*InfracostProject, string) {
+	for _, matches := range []func(*InfracostProject, *terraform.PlanWithIdentifier) bool{
+		projectMatchesPlanFile,
+		projectMatchesPlanDir,
+	} {
+		for _, report := range reports {
+			for i := range report.Projects {
+				if matches(&report.Projects[i], plan) {
+					return &report.Projects[i], report.Currency
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+func projectMatchesPlanFile(project *InfracostProject, plan *terraform.PlanWithIdentifier) bool {
+	if project.Name == plan.Name {
+		return true
+	}
+	for _, candidate := range projectPaths(project) {
+		if candidate == filepath.Clean(plan.Path) || strings.TrimSuffix(filepath.Base(candidate), ".json") == plan.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func projectMatchesPlanDir(project *InfracostProject, plan *terraform.PlanWithIdentifier) bool {
+	for _, candidate := range projectPaths(project) {
+		if candidate == filepath.Dir(filepath.Clean(plan.Path)) {
+			return true
+		}
+	}
+	return false
+}
+
+func projectPaths(project *InfracostProject) []string {
+	var paths []string
+	for _, candidate := range []string{project.Metadata.Path, project.Name} {
+		if candidate != "" {
+			paths = append(paths, filepath.Clean(candidate))
+		}
+	}
+	return paths
+}
+
+func resourceCosts(breakdown *InfracostBreakdown) map[string]float64 {
+	costs := make(map[string]float64)
+	if breakdown == nil {
+		return costs
+	}
+	for _, resource := range breakdown.Resources {
+		if cost, ok := parseCost(resource.MonthlyCost); ok {
+			costs[resource.Name] = cost
+		}
+	}
+	return costs
+}
+
+func totalCost(breakdown *InfracostBreakdown) float64 {
+	if breakdown == nil {
+		return 0
+	}
+	cost, _ := parseCost(breakdown.TotalMonthlyCost)
+	return cost
+}
+
+func parseCost(value *string) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	cost, err := strconv.ParseFloat(*value, 64)
+	return cost, err == nil
+}
@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"unicode/utf8"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// FormatWithin renders the comment at the most detailed level that fits in
+// budget characters, trying each of terraform.DetailLevels in turn. When
+// even the least detailed level does not fit, that rendering is returned.
+// A budget of zero or less disables the reduction.
+func (f *Formatter) FormatWithin(multiPlanData *terraform.MultiPlanData, budget int) (string, error) {
+	if budget <= 0 {
+		return f.Format(multiPlanData)
+	}
+
+	var body string
+	for _, level := range terraform.DetailLevels {
+		reduced := multiPlanData.WithDetail(level)
+		reduced.Display.Budget = budget
+
+		var err error
+		body, err = f.Format(reduced)
+		if err != nil {
+			return "", err
+		}
+		if utf8.RuneCountInString(body) <= budget {
+			break
+		}
+	}
+	return body, nil
+}
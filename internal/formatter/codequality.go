@@ -0,0 +1,126 @@
+package formatter
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+const (
+	checkDestroy     = "terraform-destroy"
+	checkReplace     = "terraform-replace"
+	checkRiskyChange = "terraform-risky-change"
+	checkPolicy      = "terraform-policy-destroy"
+)
+
+// CodeQualityIssue is one finding in GitLab's Code Quality report format, a
+// subset of the CodeClimate issue schema.
+type CodeQualityIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+type CodeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines CodeQualityLines `json:"lines"`
+}
+
+type CodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// NewCodeQualityReport lists destructive changes, policy violations and
+// risky attribute flips as Code Quality issues. Deletions are critical;
+// replacements and flips that remove a safeguard are major. Recreated and
+// deleted resources matching none of the allowed destroys also violate that
+// policy, a blocker, as they fail the JUnit report.
+func NewCodeQualityReport(multiPlanData *terraform.MultiPlanData, allowedDestroys []string) []CodeQualityIssue {
+	issues := []CodeQualityIssue{}
+	for _, plan := range multiPlanData.Plans {
+		disallowed := make(map[string]bool)
+		for _, resource := range plan.Data.DisallowedDestroys(allowedDestroys) {
+			disallowed[resource.Address] = true
+		}
+		for _, resources := range [][]*terraform.ResourceData{
+			plan.Data.CreatedResources,
+			plan.Data.UpdatedResources,
+			plan.Data.RecreatedResources,
+			plan.Data.DeletedResources,
+		} {
+			for _, resource := range resources {
+				for _, member := range resource.Members() {
+					issues = append(issues, resourceIssues(plan, member, disallowed[member.Address])...)
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// FormatCodeQuality renders the Code Quality report as JSON.
+func FormatCodeQuality(multiPlanData *terraform.MultiPlanData, allowedDestroys []string) (string, error) {
+	content, err := json.MarshalIndent(NewCodeQualityReport(multiPlanData, allowedDestroys), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding code quality report: %w", err)
+	}
+	return string(content) + "\n", nil
+}
+
+func resourceIssues(plan *terraform.PlanWithIdentifier, resource *terraform.ResourceData, disallowed bool) []CodeQualityIssue {
+	var issues []CodeQualityIssue
+	newIssue := func(check, severity, description string, key ...string) CodeQualityIssue {
+		return CodeQualityIssue{
+			Type:        "issue",
+			CheckName:   check,
+			Description: description,
+			Categories:  []string{"Bug Risk"},
+			Severity:    severity,
+			Fingerprint: fingerprint(append([]string{check, plan.Name, resource.Address}, key...)...),
+			Location:    issueLocation(plan, resource),
+		}
+	}
+
+	switch resource.Action {
+	case "delete":
+		issues = append(issues, newIssue(checkDestroy, "critical",
+			fmt.Sprintf("%s will be destroyed (plan %s)", resource.Address, plan.Name)))
+	case "recreate":
+		issues = append(issues, newIssue(checkReplace, "major",
+			fmt.Sprintf("%s will be destroyed and created again (plan %s)", resource.Address, plan.Name)))
+	}
+	if disallowed {
+		issues = append(issues, newIssue(checkPolicy, "blocker",
+			fmt.Sprintf("%s would be destroyed and is not in the allowed destroys (plan %s)", resource.Address, plan.Name)))
+	}
+	for _, risky := range resource.RiskyChanges {
+		issue := newIssue(checkRiskyChange, "major",
+			fmt.Sprintf("%s: %s (%s: %t → %t, plan %s)", resource.Address, risky.Reason, risky.Attribute, risky.Before, risky.After, plan.Name),
+			risky.Attribute)
+		issue.Categories = []string{"Security"}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// issueLocation points at the block declaring the resource, or at the plan
+// file when the declaration was not found.
+func issueLocation(plan *terraform.PlanWithIdentifier, resource *terraform.ResourceData) CodeQualityLocation {
+	if resource.Source != nil {
+		return CodeQualityLocation{Path: resource.Source.File, Lines: CodeQualityLines{Begin: resource.Source.Line}}
+	}
+	return CodeQualityLocation{Path: plan.Path, Lines: CodeQualityLines{Begin: 1}}
+}
+
+func fingerprint(parts ...string) string {
+	sum := md5.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
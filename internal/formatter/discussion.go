@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+const (
+	discussionTemplateName        = "resourceDiscussion"
+	destroyDiscussionTemplateName = "destroyDiscussion"
+)
+
+// Discussion is the data of an inline diff discussion: the destructive
+// changes of one plan to the resources declared by one block.
+type Discussion struct {
+	Plan      string
+	Address   string
+	Resources []*terraform.ResourceData
+}
+
+// FormatDiscussion renders the body of an inline diff discussion with the
+// "resourceDiscussion" template.
+func (f *Formatter) FormatDiscussion(discussion *Discussion) (string, error) {
+	var builder strings.Builder
+	if err := f.tmpl.ExecuteTemplate(&builder, discussionTemplateName, discussion); err != nil {
+		return "", fmt.Errorf("error executing discussion template: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// FormatDestroyDiscussion renders the body of the discussion asking for
+// destructive changes to be acknowledged, with the "destroyDiscussion"
+// template.
+func (f *Formatter) FormatDestroyDiscussion(multiPlanData *terraform.MultiPlanData) (string, error) {
+	var builder strings.Builder
+	if err := f.tmpl.ExecuteTemplate(&builder, destroyDiscussionTemplateName, multiPlanData); err != nil {
+		return "", fmt.Errorf("error executing destroy discussion template: %w", err)
+	}
+	return builder.String(), nil
+}
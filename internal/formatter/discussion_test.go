@@ -0,0 +1,89 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+func TestFormatDiscussion(t *testing.T) {
+	discussion := &Discussion{
+		Plan:    "production",
+		Address: "aws_db_instance.main",
+		Resources: []*terraform.ResourceData{
+			{
+				Address:      "aws_db_instance.main",
+				Action:       "recreate",
+				Diff:         "-engine_version = \"14.7\"\n+engine_version = \"15.4\"",
+				ReplacePaths: []string{"engine_version", "identifier"},
+			},
+			{
+				Address: "aws_db_instance.main[1]",
+				Action:  "delete",
+				Diff:    "-identifier = \"replica\"",
+			},
+		},
+	}
+
+	got, err := defaultFormatter.FormatDiscussion(discussion)
+	if err != nil {
+		t.Fatalf("FormatDiscussion() error = %v", err)
+	}
+
+	want := "**⚠️ Destructive change in plan `production`**\n" +
+		"\n" +
+		"🔄 `aws_db_instance.main` will be replaced, because `engine_version`, `identifier` cannot be updated in place.\n" +
+		"\n" +
+		"```diff\n-engine_version = \"14.7\"\n+engine_version = \"15.4\"\n```\n" +
+		"\n" +
+		"❌ `aws_db_instance.main[1]` will be destroyed.\n" +
+		"\n" +
+		"```diff\n-identifier = \"replica\"\n```"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FormatDiscussion() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFormatDestroyDiscussion(t *testing.T) {
+	tests := []struct {
+		name string
+		data *terraform.MultiPlanData
+		want string
+	}{
+		{
+			name: "destructive",
+			data: sampleData(),
+			want: "**⚠️ This merge request destroys or replaces infrastructure**\n" +
+				"\n" +
+				"Resolve this thread to acknowledge the changes below before merging. It is reopened if the set of destroyed or replaced resources changes.\n" +
+				"\n" +
+				"Plan `production`:\n" +
+				"\n" +
+				"- 🔄 `aws_db_instance.main` will be replaced\n" +
+				"- ❌ `aws_iam_user.u[2]` will be destroyed\n" +
+				"- ❌ `aws_sqs_queue.old_jobs` will be destroyed",
+		},
+		{
+			name: "no longer destructive",
+			data: &terraform.MultiPlanData{
+				HasChanges: true,
+				Summary:    terraform.ChangeSummary{Update: 1},
+			},
+			want: "✅ The latest plans no longer destroy or replace any resource.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defaultFormatter.FormatDestroyDiscussion(tt.data)
+			if err != nil {
+				t.Fatalf("FormatDestroyDiscussion() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("FormatDestroyDiscussion() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestOutputFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format func() (string, error)
+	}{
+		{name: "sample.json", format: func() (string, error) { return FormatJSON(sampleData()) }},
+		{name: "sample.txt", format: func() (string, error) { return FormatText(sampleData()) }},
+		{name: "sample.html", format: func() (string, error) { return FormatHTML(sampleData()) }},
+		{name: "sample.codequality.json", format: func() (string, error) { return FormatCodeQuality(sampleData(), []string{"aws_iam_user.u[*]"}) }},
+		{name: "sample.junit.xml", format: func() (string, error) { return FormatJUnit(sampleData(), []string{"aws_iam_user.u[*]"}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format()
+			if err != nil {
+				t.Fatalf("format error = %v", err)
+			}
+
+			goldenPath := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+					t.Fatalf("failed to write golden file: %v", err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+
+			if diff := cmp.Diff(string(want), got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatHTMLEscapes(t *testing.T) {
+	data := sampleData()
+	data.Plans[0].Name = "<script>alert(1)</script>"
+
+	got, err := FormatHTML(data)
+	if err != nil {
+		t.Fatalf("FormatHTML() error = %v", err)
+	}
+	if strings.Contains(got, "<script>") {
+		t.Errorf("FormatHTML() did not escape the plan name")
+	}
+}
+
+func TestPolicyViolationsAgree(t *testing.T) {
+	for _, allowed := range [][]string{nil, {"aws_iam_user.u[*]"}} {
+		var junitFailures, codeQualityIssues []string
+		for _, suite := range NewJUnitReport(sampleData(), allowed).Suites {
+			for _, testCase := range suite.Cases {
+				if testCase.Failure != nil && testCase.Failure.Type == "destroy" {
+					address, _, _ := strings.Cut(testCase.Name, " ")
+					junitFailures = append(junitFailures, address)
+				}
+			}
+		}
+		for _, issue := range NewCodeQualityReport(sampleData(), allowed) {
+			if issue.CheckName == checkPolicy {
+				address, _, _ := strings.Cut(issue.Description, " ")
+				codeQualityIssues = append(codeQualityIssues, address)
+			}
+		}
+		if len(junitFailures) == 0 {
+			t.Errorf("allowed %v: no disallowed destroys in the sample data", allowed)
+		}
+		if diff := cmp.Diff(junitFailures, codeQualityIssues); diff != "" {
+			t.Errorf("allowed %v: JUnit failures and Code Quality policy issues differ (-junit +codequality):\n%s", allowed, diff)
+		}
+	}
+}
+
+func TestFormatTerraformReport(t *testing.T) {
+	got, err := FormatTerraformReport(sampleData().Plans[0].Data)
+	if err != nil {
+		t.Fatalf("FormatTerraformReport() error = %v", err)
+	}
+
+	want := `{"create":2,"update":4,"delete":3}` + "\n"
+	if got != want {
+		t.Errorf("FormatTerraformReport() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,216 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// funcMap is the helper library available to the embedded template and to
+// user-provided templates.
+var funcMap = template.FuncMap{
+	// strings
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      title,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       join,
+	"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+	"indent":     indent,
+	"lines":      func(s string) []string { return strings.Split(s, "\n") },
+
+	// lists
+	"list":    func(items ...interface{}) []interface{} { return items },
+	"dict":    dict,
+	"first":   first,
+	"last":    last,
+	"reverse": reverse,
+
+	// math
+	"add": func(a, b int) int { return a + b },
+	"sub": func(a, b int) int { return a - b },
+	"mul": func(a, b int) int { return a * b },
+	"div": divide,
+	"mod": modulo,
+	"max": func(a, b int) int { return max(a, b) },
+	"min": func(a, b int) int { return min(a, b) },
+
+	// dates
+	"now":  time.Now,
+	"date": func(layout string, t time.Time) string { return t.Format(layout) },
+
+	// markdown
+	"mdEscape":  markdownEscape,
+	"code":      inlineCode,
+	"truncate":  truncate,
+	"pluralize": pluralize,
+	"anchor":    headingAnchor,
+
+	// plan data
+	"money":        formatMoney,
+	"signedMoney":  formatSignedMoney,
+	"changeCounts": formatChangeCounts,
+}
+
+func title(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// join accepts any slice so that templates can join lists of addresses or
+// the result of list.
+func join(sep string, items interface{}) (string, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", items)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// dict builds a map from alternating keys and values, which lets templates
+// pass several values to a named sub-template.
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict: expected an even number of arguments")
+	}
+	result := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
+
+func first(items interface{}) (interface{}, error) {
+	v, err := listValue("first", items)
+	if err != nil || v.Len() == 0 {
+		return nil, err
+	}
+	return v.Index(0).Interface(), nil
+}
+
+func last(items interface{}) (interface{}, error) {
+	v, err := listValue("last", items)
+	if err != nil || v.Len() == 0 {
+		return nil, err
+	}
+	return v.Index(v.Len() - 1).Interface(), nil
+}
+
+func reverse(items interface{}) ([]interface{}, error) {
+	v, err := listValue("reverse", items)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, v.Len())
+	for i := range result {
+		result[i] = v.Index(v.Len() - 1 - i).Interface()
+	}
+	return result, nil
+}
+
+func listValue(name string, items interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return v, fmt.Errorf("%s: expected a list, got %T", name, items)
+	}
+	return v, nil
+}
+
+func divide(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("div: division by zero")
+	}
+	return a / b, nil
+}
+
+func modulo(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("mod: division by zero")
+	}
+	return a % b, nil
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "{", `\{`, "}", `\}`,
+	"[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "#", `\#`, "+", `\+`,
+	"-", `\-`, "!", `\!`, "|", `\|`, "<", "&lt;", ">", "&gt;",
+)
+
+// markdownEscape escapes the characters that Markdown would otherwise
+// interpret, so that arbitrary text renders literally.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// inlineCode wraps s in a code span, using a backtick fence longer than any
+// run of backticks inside it.
+func inlineCode(s string) string {
+	longest, current := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+// truncate shortens s to at most length runes, ending in an ellipsis when
+// anything was cut.
+func truncate(length int, s string) string {
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	if length <= 1 {
+		return "…"
+	}
+	runes := []rune(s)
+	return string(runes[:length-1]) + "…"
+}
+
+// pluralize returns singular for a count of one and the plural otherwise.
+// The plural defaults to singular with an "s" appended.
+func pluralize(count int, singular string, plural ...string) string {
+	if math.Abs(float64(count)) == 1 {
+		return singular
+	}
+	if len(plural) > 0 {
+		return plural[0]
+	}
+	return singular + "s"
+}
@@ -0,0 +1,65 @@
+package formatter
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		length int
+		input  string
+		want   string
+	}{
+		{length: 10, input: "short", want: "short"},
+		{length: 5, input: "module.network", want: "modu…"},
+		{length: 3, input: "ümläut", want: "üm…"},
+		{length: 0, input: "anything", want: "…"},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.length, tt.input); got != tt.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", tt.length, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		count  int
+		plural []string
+		want   string
+	}{
+		{count: 1, want: "resource"},
+		{count: 0, want: "resources"},
+		{count: 2, want: "resources"},
+		{count: 2, plural: []string{"resourcen"}, want: "resourcen"},
+	}
+
+	for _, tt := range tests {
+		if got := pluralize(tt.count, "resource", tt.plural...); got != tt.want {
+			t.Errorf("pluralize(%d) = %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestInlineCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "aws_s3_bucket.logs", want: "`aws_s3_bucket.logs`"},
+		{input: "a`b", want: "`` a`b ``"},
+	}
+
+	for _, tt := range tests {
+		if got := inlineCode(tt.input); got != tt.want {
+			t.Errorf("inlineCode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownEscape(t *testing.T) {
+	got := markdownEscape("aws_s3_bucket.logs[*] <b>")
+	want := `aws\_s3\_bucket.logs\[\*\] &lt;b&gt;`
+	if got != want {
+		t.Errorf("markdownEscape() = %q, want %q", got, want)
+	}
+}
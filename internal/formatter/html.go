@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/templates"
+)
+
+var htmlTmpl = template.Must(template.New("report.html.tmpl").Funcs(template.FuncMap{
+	"money":       formatMoney,
+	"signedMoney": formatSignedMoney,
+	"diffLines":   diffLines,
+}).Parse(templates.HTMLTemplateContent))
+
+// diffLine is one line of a diff with the CSS class used to highlight it.
+type diffLine struct {
+	Class string
+	Text  string
+}
+
+func diffLines(diff string) []diffLine {
+	lines := strings.Split(diff, "\n")
+	result := make([]diffLine, 0, len(lines))
+	for _, line := range lines {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		result = append(result, diffLine{Class: class, Text: line})
+	}
+	return result
+}
+
+// FormatHTML renders plan data as a self-contained HTML report with inline
+// styles and no external assets, so it can be browsed as a job artifact.
+func FormatHTML(multiPlanData *terraform.MultiPlanData) (string, error) {
+	var builder strings.Builder
+	if err := htmlTmpl.Execute(&builder, multiPlanData); err != nil {
+		return "", fmt.Errorf("error executing HTML template: %w", err)
+	}
+
+	return builder.String(), nil
+}
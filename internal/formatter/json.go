@@ -0,0 +1,239 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// JSONSchemaVersion is bumped whenever a field of the JSON report is renamed,
+// removed or changes meaning. Adding fields does not change the version.
+const JSONSchemaVersion = 1
+
+// JSONReport is the machine-readable summary of a set of plans. It mirrors
+// terraform.MultiPlanData but is decoupled from it, so that internal changes
+// do not break downstream consumers.
+type JSONReport struct {
+	SchemaVersion int              `json:"schema_version"`
+	HasChanges    bool             `json:"has_changes"`
+	Summary       JSONSummary      `json:"summary"`
+	Cost          *JSONCost        `json:"cost,omitempty"`
+	ByType        []JSONSummaryRow `json:"by_type"`
+	ByProvider    []JSONSummaryRow `json:"by_provider"`
+	Plans         []JSONPlan       `json:"plans"`
+}
+
+type JSONSummary struct {
+	Create   int `json:"create"`
+	Update   int `json:"update"`
+	Recreate int `json:"recreate"`
+	Delete   int `json:"delete"`
+}
+
+type JSONSummaryRow struct {
+	Name    string      `json:"name"`
+	Summary JSONSummary `json:"summary"`
+}
+
+type JSONCost struct {
+	Currency string  `json:"currency"`
+	Before   float64 `json:"before"`
+	After    float64 `json:"after"`
+	Delta    float64 `json:"delta"`
+}
+
+type JSONPlan struct {
+	Name        string           `json:"name"`
+	Path        string           `json:"path"`
+	HasChanges  bool             `json:"has_changes"`
+	Destructive bool             `json:"destructive"`
+	Summary     JSONSummary      `json:"summary"`
+	Cost        *JSONCost        `json:"cost,omitempty"`
+	ByType      []JSONSummaryRow `json:"by_type"`
+	ByProvider  []JSONSummaryRow `json:"by_provider"`
+	Resources   []JSONResource   `json:"resources"`
+	Moves       []JSONMove       `json:"moves"`
+	IndexShifts []JSONIndexShift `json:"index_shifts"`
+}
+
+// JSONResource is one changed resource instance. Instances grouped in the
+// comment because of identical diffs are listed individually here.
+type JSONResource struct {
+	Address       string            `json:"address"`
+	ModuleAddress string            `json:"module_address,omitempty"`
+	Type          string            `json:"type"`
+	Provider      string            `json:"provider"`
+	Action        string            `json:"action"`
+	Diff          string            `json:"diff"`
+	Cost          *JSONCost         `json:"cost,omitempty"`
+	IndexShifted  bool              `json:"index_shifted"`
+	RiskyChanges  []JSONRiskyChange `json:"risky_changes"`
+	ReplacePaths  []string          `json:"replace_paths"`
+	Dependents    []JSONDependent   `json:"dependents"`
+	Source        *JSONSource       `json:"source,omitempty"`
+}
+
+type JSONSource struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	URL  string `json:"url,omitempty"`
+}
+
+type JSONRiskyChange struct {
+	Attribute string `json:"attribute"`
+	Before    bool   `json:"before"`
+	After     bool   `json:"after"`
+	Reason    string `json:"reason"`
+}
+
+type JSONDependent struct {
+	Address  string `json:"address"`
+	Kind     string `json:"kind"`
+	Changing bool   `json:"changing"`
+}
+
+type JSONMove struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Similarity float64 `json:"similarity"`
+}
+
+type JSONIndexShift struct {
+	Base    string   `json:"base"`
+	Removed string   `json:"removed"`
+	Shifted []string `json:"shifted"`
+	Deleted string   `json:"deleted"`
+}
+
+// NewJSONReport converts plan data into the versioned JSON schema.
+func NewJSONReport(multiPlanData *terraform.MultiPlanData) *JSONReport {
+	report := &JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		HasChanges:    multiPlanData.HasChanges,
+		Summary:       jsonSummary(multiPlanData.Summary),
+		Cost:          jsonCost(multiPlanData.Cost),
+		ByType:        jsonSummaryRows(multiPlanData.ByType),
+		ByProvider:    jsonSummaryRows(multiPlanData.ByProvider),
+		Plans:         make([]JSONPlan, 0, len(multiPlanData.Plans)),
+	}
+	for _, plan := range multiPlanData.Plans {
+		report.Plans = append(report.Plans, jsonPlan(plan))
+	}
+	return report
+}
+
+// FormatJSON renders plan data as an indented JSON report.
+func FormatJSON(multiPlanData *terraform.MultiPlanData) (string, error) {
+	content, err := json.MarshalIndent(NewJSONReport(multiPlanData), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding JSON report: %w", err)
+	}
+	return string(content) + "\n", nil
+}
+
+func jsonPlan(plan *terraform.PlanWithIdentifier) JSONPlan {
+	data := plan.Data
+	result := JSONPlan{
+		Name:        plan.Name,
+		Path:        plan.Path,
+		HasChanges:  data.HasChanges,
+		Destructive: data.HasDestructiveChanges(),
+		Summary:     jsonSummary(data.Summary),
+		Cost:        jsonCost(data.Cost),
+		ByType:      jsonSummaryRows(data.ByType),
+		ByProvider:  jsonSummaryRows(data.ByProvider),
+		Resources:   []JSONResource{},
+		Moves:       make([]JSONMove, 0, len(data.Moves)),
+		IndexShifts: make([]JSONIndexShift, 0, len(data.IndexShifts)),
+	}
+
+	for _, resources := range [][]*terraform.ResourceData{
+		data.CreatedResources,
+		data.UpdatedResources,
+		data.RecreatedResources,
+		data.DeletedResources,
+	} {
+		for _, resource := range resources {
+			for _, member := range resource.Members() {
+				result.Resources = append(result.Resources, jsonResource(member))
+			}
+		}
+	}
+	for _, move := range data.Moves {
+		result.Moves = append(result.Moves, JSONMove{From: move.From, To: move.To, Similarity: move.Similarity})
+	}
+	for _, shift := range data.IndexShifts {
+		result.IndexShifts = append(result.IndexShifts, JSONIndexShift{
+			Base:    shift.Base,
+			Removed: shift.Removed,
+			Shifted: shift.Shifted,
+			Deleted: shift.Deleted,
+		})
+	}
+	return result
+}
+
+func jsonResource(resource *terraform.ResourceData) JSONResource {
+	result := JSONResource{
+		Address:       resource.Address,
+		ModuleAddress: resource.ModuleAddress,
+		Type:          resource.Type,
+		Provider:      resource.ProviderName,
+		Action:        resource.Action,
+		Diff:          resource.Diff,
+		Cost:          jsonCost(resource.Cost),
+		IndexShifted:  resource.IndexShifted,
+		RiskyChanges:  make([]JSONRiskyChange, 0, len(resource.RiskyChanges)),
+		ReplacePaths:  append([]string{}, resource.ReplacePaths...),
+		Dependents:    make([]JSONDependent, 0, len(resource.Dependents)),
+	}
+	if resource.Source != nil {
+		result.Source = &JSONSource{File: resource.Source.File, Line: resource.Source.Line, URL: resource.Source.URL}
+	}
+	for _, risky := range resource.RiskyChanges {
+		result.RiskyChanges = append(result.RiskyChanges, JSONRiskyChange{
+			Attribute: risky.Attribute,
+			Before:    risky.Before,
+			After:     risky.After,
+			Reason:    risky.Reason,
+		})
+	}
+	for _, dependent := range resource.Dependents {
+		result.Dependents = append(result.Dependents, JSONDependent{
+			Address:  dependent.Address,
+			Kind:     dependent.Kind,
+			Changing: dependent.Changing,
+		})
+	}
+	return result
+}
+
+func jsonSummary(summary terraform.ChangeSummary) JSONSummary {
+	return JSONSummary{
+		Create:   summary.Create,
+		Update:   summary.Update,
+		Recreate: summary.Recreate,
+		Delete:   summary.Delete,
+	}
+}
+
+func jsonSummaryRows(rows []*terraform.SummaryRow) []JSONSummaryRow {
+	result := make([]JSONSummaryRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, JSONSummaryRow{Name: row.Name, Summary: jsonSummary(row.Summary)})
+	}
+	return result
+}
+
+func jsonCost(cost *terraform.CostDelta) *JSONCost {
+	if cost == nil {
+		return nil
+	}
+	return &JSONCost{
+		Currency: cost.Currency,
+		Before:   cost.Before,
+		After:    cost.After,
+		Delta:    cost.Delta(),
+	}
+}
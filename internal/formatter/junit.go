@@ -0,0 +1,147 @@
+package formatter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report. Each plan is a test
+// suite; failed checks and disallowed destroys are failing test cases, and
+// every other changed or unchanged resource is a passing one. Disallowed
+// destroys are the same policy violations the Code Quality report lists.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *JUnitProblem `xml:"failure,omitempty"`
+	Error     *JUnitProblem `xml:"error,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+type JUnitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// NewJUnitReport builds the JUnit report. Recreated and deleted resources
+// fail unless their address matches one of allowedDestroys.
+func NewJUnitReport(multiPlanData *terraform.MultiPlanData, allowedDestroys []string) *JUnitTestSuites {
+	report := &JUnitTestSuites{Name: "terraform plan"}
+	for _, plan := range multiPlanData.Plans {
+		suite := junitSuite(plan, allowedDestroys)
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+	return report
+}
+
+// FormatJUnit renders the JUnit report as XML.
+func FormatJUnit(multiPlanData *terraform.MultiPlanData, allowedDestroys []string) (string, error) {
+	content, err := xml.MarshalIndent(NewJUnitReport(multiPlanData, allowedDestroys), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding JUnit report: %w", err)
+	}
+	return xml.Header + string(content) + "\n", nil
+}
+
+func junitSuite(plan *terraform.PlanWithIdentifier, allowedDestroys []string) JUnitTestSuite {
+	suite := JUnitTestSuite{Name: plan.Name}
+	add := func(testCase JUnitTestCase) {
+		testCase.ClassName = plan.Name
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+
+	for _, check := range plan.Data.Checks {
+		add(checkCase(check))
+	}
+
+	disallowed := make(map[string]bool)
+	for _, resource := range plan.Data.DisallowedDestroys(allowedDestroys) {
+		disallowed[resource.Address] = true
+	}
+	for _, resources := range [][]*terraform.ResourceData{
+		plan.Data.CreatedResources,
+		plan.Data.UpdatedResources,
+		plan.Data.RecreatedResources,
+		plan.Data.DeletedResources,
+	} {
+		for _, resource := range resources {
+			for _, member := range resource.Members() {
+				testCase := JUnitTestCase{Name: fmt.Sprintf("%s (%s)", member.Address, member.Action)}
+				if disallowed[member.Address] {
+					testCase.Failure = &JUnitProblem{
+						Message: fmt.Sprintf("%s would be destroyed and is not in the allowed destroys", member.Address),
+						Type:    "destroy",
+						Body:    member.Diff,
+					}
+				}
+				add(testCase)
+			}
+		}
+	}
+
+	for _, address := range plan.Data.UnchangedResources {
+		add(JUnitTestCase{Name: address + " (no changes)"})
+	}
+	return suite
+}
+
+var checkKindLabels = map[string]string{
+	"check":        "check block",
+	"resource":     "resource condition",
+	"output_value": "output condition",
+}
+
+func checkCase(check *terraform.CheckResult) JUnitTestCase {
+	testCase := JUnitTestCase{Name: fmt.Sprintf("check %s", check.Address)}
+	label := checkKindLabels[check.Kind]
+	if label == "" {
+		label = check.Kind
+	}
+	problems := strings.Join(check.Problems, "\n")
+	switch check.Status {
+	case "fail":
+		testCase.Failure = &JUnitProblem{Message: fmt.Sprintf("%s failed", label), Type: "check", Body: problems}
+	case "error":
+		testCase.Error = &JUnitProblem{Message: fmt.Sprintf("%s could not be evaluated", label), Type: "check", Body: problems}
+	case "unknown":
+		testCase.Skipped = &JUnitSkipped{Message: "result is not known until apply"}
+	}
+	return testCase
+}
@@ -1,23 +1,200 @@
 package formatter
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"gitlab-terraform-mr-commenter/internal/terraform"
 	"gitlab-terraform-mr-commenter/templates"
 )
 
-var planTmpl = template.Must(template.New("plan.md.tmpl").Funcs(template.FuncMap{
-	"sub": func(a, b int) int { return a - b },
-}).Parse(templates.PlanTemplateContent))
+const rootTemplateName = "plan.md.tmpl"
 
-func FormatPlan(multiPlanData *terraform.MultiPlanData) (string, error) {
+var defaultFormatter = &Formatter{
+	tmpl: template.Must(template.New(rootTemplateName).Funcs(funcMap).Parse(templates.PlanTemplateContent)),
+}
+
+// Formatter renders plan data into a merge request comment.
+type Formatter struct {
+	tmpl *template.Template
+}
+
+// New returns a formatter for the template at templatePath, or for the
+// embedded template when templatePath is empty. templatePath may be a single
+// file or a directory of *.tmpl files. User files are parsed on top of the
+// embedded template, so they can override named sub-templates such as
+// "resourceDiff" without replacing the whole comment; a file with top-level
+// content, or one named plan.md.tmpl, replaces the comment layout itself.
+// The result is checked against sample data so that a broken template fails
+// before any plan is processed.
+func New(templatePath string) (*Formatter, error) {
+	if templatePath == "" {
+		return defaultFormatter, nil
+	}
+
+	files, err := templateFiles(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := defaultFormatter.tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("error cloning embedded template: %w", err)
+	}
+
+	root := rootTemplateName
+	var roots []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", file, err)
+		}
+
+		name := filepath.Base(file)
+		parsed, err := tmpl.New(name).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		if name != rootTemplateName && hasContent(parsed) {
+			roots = append(roots, name)
+		}
+	}
+
+	switch {
+	case slices.ContainsFunc(files, func(f string) bool { return filepath.Base(f) == rootTemplateName }):
+	case len(roots) == 1:
+		root = roots[0]
+	case len(roots) > 1:
+		return nil, fmt.Errorf("invalid template %s: several files have top-level content (%s); name the main one %s", templatePath, strings.Join(roots, ", "), rootTemplateName)
+	}
+
+	formatter := &Formatter{tmpl: tmpl.Lookup(root)}
+	if err := formatter.validate(); err != nil {
+		return nil, err
+	}
+
+	return formatter, nil
+}
+
+func (f *Formatter) Format(multiPlanData *terraform.MultiPlanData) (string, error) {
 	var builder strings.Builder
-	if err := planTmpl.Execute(&builder, multiPlanData); err != nil {
+	if err := f.tmpl.Execute(&builder, multiPlanData); err != nil {
 		return "", fmt.Errorf("error executing template: %w", err)
 	}
 
 	return builder.String(), nil
 }
+
+func FormatPlan(multiPlanData *terraform.MultiPlanData) (string, error) {
+	return defaultFormatter.Format(multiPlanData)
+}
+
+// validate renders sample data in every layout the template supports.
+func (f *Formatter) validate() error {
+	for _, groupBy := range []string{terraform.GroupByAction, terraform.GroupByModule} {
+		data := sampleData()
+		data.Display.GroupBy = groupBy
+		if err := f.tmpl.Execute(io.Discard, data); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	if err := f.tmpl.ExecuteTemplate(io.Discard, discussionTemplateName, sampleDiscussion()); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if err := f.tmpl.ExecuteTemplate(io.Discard, destroyDiscussionTemplateName, sampleData()); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
+
+func templateFiles(templatePath string) ([]string, error) {
+	info, err := os.Stat(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template %s: %w", templatePath, err)
+	}
+	if !info.IsDir() {
+		return []string{templatePath}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(templatePath, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates in %s: %w", templatePath, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no *.tmpl files found in %s", templatePath)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// hasContent reports whether a parsed template produces output of its own,
+// as opposed to only defining named sub-templates.
+func hasContent(tmpl *template.Template) bool {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return false
+	}
+	for _, node := range tmpl.Tree.Root.Nodes {
+		if text, ok := node.(*parse.TextNode); ok && len(bytes.TrimSpace(text.Text)) == 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+var (
+	anchorPunctuationRe = regexp.MustCompile(`[^\p{L}\p{N}_\- ]`)
+	anchorHyphensRe     = regexp.MustCompile(`-+`)
+)
+
+// headingAnchor returns the fragment GitLab assigns to a Markdown heading:
+// lowercased, punctuation removed and spaces turned into hyphens.
+func headingAnchor(heading string) string {
+	anchor := anchorPunctuationRe.ReplaceAllString(strings.ToLower(heading), "")
+	anchor = strings.ReplaceAll(anchor, " ", "-")
+	return "#" + anchorHyphensRe.ReplaceAllString(anchor, "-")
+}
+
+// formatChangeCounts renders a summary such as "+2 ~1 ±1", leaving out
+// actions without changes.
+func formatChangeCounts(summary terraform.ChangeSummary) string {
+	var parts []string
+	for _, count := range []struct {
+		symbol string
+		n      int
+	}{
+		{"+", summary.Create},
+		{"~", summary.Update},
+		{"-", summary.Delete},
+		{"±", summary.Recreate},
+	} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%s%d", count.symbol, count.n))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatMoney(amount float64, currency string) string {
+	if currency == "USD" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+func formatSignedMoney(amount float64, currency string) string {
+	sign := "+"
+	if amount < 0 {
+		sign = "-"
+	}
+	return sign + formatMoney(math.Abs(amount), currency)
+}
@@ -0,0 +1,258 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{heading: "Plan: prod", want: "#plan-prod"},
+		{heading: "Plan: eu-west-1.networking", want: "#plan-eu-west-1networking"},
+		{heading: "Plan: Staging  (blue)", want: "#plan-staging-blue"},
+		{heading: "Plan: tf_state_v2", want: "#plan-tf_state_v2"},
+		{heading: "Plan: staging/plan", want: "#plan-stagingplan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.heading, func(t *testing.T) {
+			if got := headingAnchor(tt.heading); got != tt.want {
+				t.Errorf("headingAnchor(%q) = %q, want %q", tt.heading, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatPlanOverview(t *testing.T) {
+	multiPlanData := &terraform.MultiPlanData{
+		HasChanges: true,
+		Plans: []*terraform.PlanWithIdentifier{
+			{Name: "dev", Data: &terraform.PlanData{}},
+			{
+				Name: "prod",
+				Data: &terraform.PlanData{
+					HasChanges:       true,
+					Summary:          terraform.ChangeSummary{Delete: 1},
+					DeletedResources: []*terraform.ResourceData{{Address: "aws_s3_bucket.logs", Action: "delete", Diff: "-bucket = \"logs\""}},
+				},
+			},
+		},
+	}
+
+	got, err := FormatPlan(multiPlanData)
+	if err != nil {
+		t.Fatalf("FormatPlan() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"| [dev](#plan-dev) | – | – | – | – | no changes |",
+		"| [prod](#plan-prod) | 0 | 0 | 0 | 1 | ⚠️ destructive |",
+		"### Plan: dev\n\nNo changes.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPlan() output missing %q", want)
+		}
+	}
+}
+
+func TestFormatPlanResourceDetails(t *testing.T) {
+	multiPlanData := &terraform.MultiPlanData{
+		HasChanges: true,
+		Plans: []*terraform.PlanWithIdentifier{
+			{
+				Name: "prod",
+				Data: &terraform.PlanData{
+					HasChanges:       true,
+					Summary:          terraform.ChangeSummary{Update: 1, Delete: 1},
+					UpdatedResources: []*terraform.ResourceData{{Address: "aws_instance.web", Action: "update", Diff: "-ami = \"one\"\n+ami = \"two\"\n+name = \"web\""}},
+					DeletedResources: []*terraform.ResourceData{{Address: "aws_s3_bucket.logs", Action: "delete", Diff: "-bucket = \"logs\""}},
+				},
+			},
+		},
+	}
+
+	got, err := FormatPlan(multiPlanData)
+	if err != nil {
+		t.Fatalf("FormatPlan() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"#### 🔄 Change (1)\n\n- `aws_instance.web`\n\n<details>\n<summary>🔄 <code>aws_instance.web</code> · +2/-1 lines</summary>",
+		"#### ❌ Destroy (1)\n\n- `aws_s3_bucket.logs`\n\n<details open>\n<summary>❌ <code>aws_s3_bucket.logs</code> · +0/-1 lines</summary>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPlan() output missing %q", want)
+		}
+	}
+}
+
+func TestFormatPlanNestedModuleHeadings(t *testing.T) {
+	created := []*terraform.ResourceData{
+		{Address: "module.a.aws_s3_bucket.logs", ModuleAddress: "module.a", Action: "create", Diff: "+bucket = \"logs\""},
+		{
+			Address:       "module.a.module.b.aws_subnet.s[*]",
+			ModuleAddress: "module.a.module.b",
+			Action:        "create",
+			Diff:          "+cidr_block = \"10.0.0.0/24\"",
+			Instances:     []*terraform.ResourceData{{}, {}, {}, {}, {}},
+		},
+	}
+	multiPlanData := &terraform.MultiPlanData{
+		HasChanges: true,
+		Display:    terraform.Display{GroupBy: terraform.GroupByModule},
+		Plans: []*terraform.PlanWithIdentifier{
+			{
+				Name: "prod",
+				Data: &terraform.PlanData{
+					HasChanges:       true,
+					Summary:          terraform.ChangeSummary{Create: 6},
+					CreatedResources: created,
+				},
+			},
+		},
+	}
+
+	got, err := FormatPlan(multiPlanData)
+	if err != nil {
+		t.Fatalf("FormatPlan() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"**`module.a`** (+6)\n\n</summary>\n\n#### ✅ Add (1)\n",
+		"**`module.a.module.b`** (+5)\n\n</summary>\n\n#### ✅ Add (5)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPlan() output missing %q\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatPlanDependents(t *testing.T) {
+	dependents := []*terraform.Dependent{
+		{Address: "aws_route53_record.db", Kind: terraform.DependentResource},
+		{Address: "output.db_endpoint", Kind: terraform.DependentOutput, Changing: true},
+	}
+	data := &terraform.PlanData{
+		HasChanges: true,
+		UpdatedResources: []*terraform.ResourceData{
+			{Address: "aws_security_group.db", Action: "update", Diff: "-name = \"a\"\n+name = \"b\"", Dependents: dependents},
+		},
+		DeletedResources: []*terraform.ResourceData{
+			{Address: "aws_db_instance.main", Action: "delete", Diff: "-name = \"db\"", Dependents: dependents},
+		},
+	}
+	multiPlanData := &terraform.MultiPlanData{
+		HasChanges: true,
+		Plans:      []*terraform.PlanWithIdentifier{{Name: "prod", Data: data}},
+	}
+
+	got, err := FormatPlan(multiPlanData)
+	if err != nil {
+		t.Fatalf("FormatPlan() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<summary>Affected dependents (2)</summary>",
+		"<summary>⚠️ Affected dependents (2, 1 not changing in this plan)</summary>",
+	} {
+		if strings.Count(got, want) != 1 {
+			t.Errorf("FormatPlan() output should contain %q once\n%s", want, got)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		templatePath string
+		want         []string
+		wantErr      string
+	}{
+		{
+			name:         "overrides a sub-template",
+			templatePath: "testdata/partial",
+			want:         []string{"## Terraform Plan Summary", "- `aws_db_instance.main` (recreate)"},
+		},
+		{
+			name:         "replaces the layout",
+			templatePath: "testdata/layout/comment.tmpl",
+			want:         []string{"PRODUCTION: +1 ~4 -2 ±1\nSTAGING: "},
+		},
+		{
+			name:         "reports the failing line",
+			templatePath: "testdata/broken.tmpl",
+			wantErr:      "broken.tmpl:3",
+		},
+		{
+			name:         "missing template",
+			templatePath: "testdata/missing.tmpl",
+			wantErr:      "failed to open template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.templatePath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			got, err := f.Format(sampleData())
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Format() output missing %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatWithin(t *testing.T) {
+	f, err := New("")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	full, err := f.Format(sampleData())
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		budget int
+		want   string
+	}{
+		{name: "disabled", budget: 0, want: "```diff\n-instance_type"},
+		{name: "fits", budget: len(full), want: "```diff\n-instance_type"},
+		{name: "counts only", budget: 100, want: "only change counts are shown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.FormatWithin(sampleData(), tt.budget)
+			if err != nil {
+				t.Fatalf("FormatWithin() error = %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("FormatWithin() output missing %q, got:\n%s", tt.want, got)
+			}
+			if tt.budget > 0 && tt.budget >= len(full) && got != full {
+				t.Errorf("FormatWithin() reduced a comment that fits")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package formatter
+
+import (
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// sampleData returns plan data that exercises every section of the comment,
+// used to check templates before real plans are rendered.
+func sampleData() *terraform.MultiPlanData {
+	cost := func(before, after float64) *terraform.CostDelta {
+		return &terraform.CostDelta{Currency: "USD", Before: before, After: after}
+	}
+
+	web := &terraform.ResourceData{
+		Address:      "aws_instance.web",
+		Type:         "aws_instance",
+		ProviderName: "registry.terraform.io/hashicorp/aws",
+		Action:       "update",
+		Diff:         "-instance_type = \"t3.micro\"\n+instance_type = \"t3.medium\"",
+		Cost:         cost(7.59, 30.37),
+		Source: &terraform.SourceLocation{
+			File: "main.tf",
+			Line: 12,
+			URL:  "https://gitlab.example.com/infra/live/-/blob/4f1c2d9/main.tf#L12",
+		},
+	}
+	subnetA := &terraform.ResourceData{
+		Address:       `module.network.aws_subnet.private["a"]`,
+		ModuleAddress: "module.network",
+		Type:          "aws_subnet",
+		ProviderName:  "registry.terraform.io/hashicorp/aws",
+		Action:        "update",
+		Diff:          " tags = {\n-  Team = \"net\"\n+  Team = \"platform\"\n }",
+	}
+	subnetB := *subnetA
+	subnetB.Address = `module.network.aws_subnet.private["b"]`
+	subnets := *subnetA
+	subnets.Address = `module.network.aws_subnet.private["*"]`
+	subnets.Instances = []*terraform.ResourceData{subnetA, &subnetB}
+
+	database := &terraform.ResourceData{
+		Address:      "aws_db_instance.main",
+		Type:         "aws_db_instance",
+		ProviderName: "registry.terraform.io/hashicorp/aws",
+		Action:       "recreate",
+		Diff:         "-engine_version = \"14.7\"\n+engine_version = \"15.4\"",
+		Cost:         cost(24.82, 24.82),
+		ReplacePaths: []string{"engine_version"},
+		Source:       &terraform.SourceLocation{File: "database.tf", Line: 3, EndLine: 9},
+		RiskyChanges: []*terraform.RiskyChange{
+			{Attribute: "skip_final_snapshot", Before: false, After: true, Reason: "no final snapshot will be taken on deletion"},
+		},
+		Dependents: []*terraform.Dependent{
+			{Address: "aws_route53_record.db", Kind: terraform.DependentResource},
+			{Address: "output.db_endpoint", Kind: terraform.DependentOutput, Changing: true},
+		},
+	}
+	queue := &terraform.ResourceData{
+		Address:      "aws_sqs_queue.jobs",
+		Type:         "aws_sqs_queue",
+		ProviderName: "registry.terraform.io/hashicorp/aws",
+		Action:       "create",
+		Diff:         "+name = \"jobs\"",
+	}
+	users := []*terraform.ResourceData{
+		{Address: "aws_iam_user.u[1]", Type: "aws_iam_user", Action: "update", Diff: "-name = \"bob\"\n+name = \"carol\"", IndexShifted: true},
+		{Address: "aws_iam_user.u[2]", Type: "aws_iam_user", Action: "delete", Diff: "-name = \"carol\"", IndexShifted: true},
+	}
+	oldQueue := &terraform.ResourceData{
+		Address: "aws_sqs_queue.old_jobs",
+		Type:    "aws_sqs_queue",
+		Action:  "delete",
+		Diff:    "-name = \"jobs\"",
+	}
+
+	summary := terraform.ChangeSummary{Create: 1, Update: 4, Recreate: 1, Delete: 2}
+	byType := []*terraform.SummaryRow{
+		{Name: "aws_db_instance", Summary: terraform.ChangeSummary{Recreate: 1}},
+		{Name: "aws_iam_user", Summary: terraform.ChangeSummary{Update: 1, Delete: 1}},
+		{Name: "aws_instance", Summary: terraform.ChangeSummary{Update: 1}},
+		{Name: "aws_sqs_queue", Summary: terraform.ChangeSummary{Create: 1, Delete: 1}},
+		{Name: "aws_subnet", Summary: terraform.ChangeSummary{Update: 2}},
+	}
+	byProvider := []*terraform.SummaryRow{{Name: "hashicorp/aws", Summary: summary}}
+
+	prod := &terraform.PlanData{
+		HasChanges:         true,
+		Summary:            summary,
+		ByType:             byType,
+		ByProvider:         byProvider,
+		CreatedResources:   []*terraform.ResourceData{queue},
+		UpdatedResources:   []*terraform.ResourceData{web, users[0], &subnets},
+		RecreatedResources: []*terraform.ResourceData{database},
+		DeletedResources:   []*terraform.ResourceData{users[1], oldQueue},
+		Cost:               cost(32.41, 55.19),
+		Graph: &terraform.ChangeGraph{
+			Nodes: []*terraform.GraphNode{
+				{ID: "n0", Label: "aws_db_instance.main", Action: "recreate"},
+				{ID: "n1", Label: "aws_instance.web", Action: "update"},
+			},
+			Edges: []*terraform.GraphEdge{{From: "n0", To: "n1"}},
+		},
+		Moves: []*terraform.MoveSuggestion{
+			{From: "aws_sqs_queue.old_jobs", To: "aws_sqs_queue.jobs", Similarity: 1},
+		},
+		IndexShifts: []*terraform.IndexShift{
+			{
+				Base:        "aws_iam_user.u",
+				Removed:     "aws_iam_user.u[1]",
+				RemovedDiff: "-name = \"bob\"",
+				Shifted:     []string{"aws_iam_user.u[1]"},
+				Deleted:     "aws_iam_user.u[2]",
+			},
+		},
+		Checks: []*terraform.CheckResult{
+			{Address: "check.health", Kind: "check", Status: "fail", Problems: []string{"The service is not healthy."}},
+			{Address: "aws_instance.web", Kind: "resource", Status: "pass"},
+		},
+		UnchangedResources: []string{"aws_vpc.main"},
+	}
+
+	return &terraform.MultiPlanData{
+		HasChanges: true,
+		Summary:    summary,
+		ByType:     byType,
+		ByProvider: byProvider,
+		Cost:       cost(32.41, 55.19),
+		Display: terraform.Display{
+			Graph:      true,
+			GroupBy:    terraform.GroupByAction,
+			ByProvider: true,
+		},
+		Plans: []*terraform.PlanWithIdentifier{
+			{Name: "production", Path: "production.json", Data: prod},
+			{Name: "staging", Path: "staging.json", Data: &terraform.PlanData{}},
+		},
+	}
+}
+
+// sampleDiscussion returns discussion data for the recreated database of the
+// sample plan, used to check the discussion template.
+func sampleDiscussion() *Discussion {
+	plan := sampleData().Plans[0]
+	return &Discussion{
+		Plan:      plan.Name,
+		Address:   "aws_db_instance.main",
+		Resources: plan.Data.RecreatedResources,
+	}
+}
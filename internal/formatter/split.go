@@ -0,0 +1,185 @@
+package formatter
+
+import (
+	"strings"
+)
+
+const continuedSummary = "<details>\n<summary>\n\n**Continued from the previous part**\n\n</summary>\n\n"
+
+// splitState is the Markdown context open at a point of the comment: the
+// number of enclosing <details> blocks and the opening line of an unclosed
+// code fence.
+type splitState struct {
+	details int
+	fence   string
+}
+
+// closing returns the text that closes everything open in the state.
+func (s splitState) closing() string {
+	var b strings.Builder
+	if s.fence != "" {
+		b.WriteString("```\n")
+	}
+	for range s.details {
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
+
+// reopening returns the text that reopens the state at the top of a part.
+func (s splitState) reopening() string {
+	var b strings.Builder
+	for range s.details {
+		b.WriteString(continuedSummary)
+	}
+	b.WriteString(s.fence)
+	return b.String()
+}
+
+func (s splitState) advance(line string) splitState {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "```") {
+		if s.fence == "" {
+			s.fence = line
+		} else if trimmed == "```" {
+			s.fence = ""
+		}
+		return s
+	}
+	if s.fence == "" {
+		s.details += strings.Count(line, "<details") - strings.Count(line, "</details>")
+		s.details = max(s.details, 0)
+	}
+	return s
+}
+
+// segment is a run of lines together with the state at its start.
+type segment struct {
+	text  string
+	state splitState
+}
+
+type splitter struct {
+	limit   int
+	parts   []string
+	current strings.Builder
+}
+
+// SplitComment cuts a comment into parts of at most limit bytes. Cuts are
+// made before plan and section headings, <details> blocks and horizontal
+// rules; a section larger than limit on its own is cut between lines. Code
+// fences and <details> blocks open at a cut are closed at the end of the
+// part and reopened at the start of the next one.
+func SplitComment(body string, limit int) []string {
+	if len(body) <= limit {
+		return []string{body}
+	}
+
+	s := &splitter{limit: limit}
+	blocks, end := splitBlocks(body)
+	for i, block := range blocks {
+		next := end
+		if i+1 < len(blocks) {
+			next = blocks[i+1].state
+		}
+		if !s.fits(block.text, next) && s.current.Len() > 0 {
+			s.cut(block.state)
+		}
+		if s.fits(block.text, next) {
+			s.current.WriteString(block.text)
+			continue
+		}
+		for _, line := range splitLines(block) {
+			s.addLine(line)
+		}
+	}
+	if s.current.Len() > 0 {
+		s.parts = append(s.parts, s.current.String())
+	}
+	return s.parts
+}
+
+func (s *splitter) fits(text string, after splitState) bool {
+	return s.current.Len()+len(text)+len(after.closing()) <= s.limit
+}
+
+// cut closes the current part in the given state and starts the next one.
+func (s *splitter) cut(state splitState) {
+	s.current.WriteString(state.closing())
+	s.parts = append(s.parts, s.current.String())
+	s.current.Reset()
+	s.current.WriteString(state.reopening())
+}
+
+// addLine adds one line to the current part, starting a new part first when
+// the line would not fit.
+func (s *splitter) addLine(line segment) {
+	after := line.state.advance(line.text)
+	if s.fits(line.text, after) {
+		s.current.WriteString(line.text)
+		return
+	}
+	s.cut(line.state)
+
+	// A single line longer than a whole part is hard cut.
+	text := line.text
+	for !s.fits(text, after) {
+		n := runeBoundary(text, s.limit-s.current.Len()-len(line.state.closing()))
+		if n <= 0 {
+			break
+		}
+		s.current.WriteString(text[:n])
+		s.cut(line.state)
+		text = text[n:]
+	}
+	s.current.WriteString(text)
+}
+
+// splitBlocks cuts the body before every boundary line that is not inside
+// a code fence, returning the blocks and the state at the end of the body.
+func splitBlocks(body string) ([]segment, splitState) {
+	var blocks []segment
+	var current strings.Builder
+	var state, start splitState
+	for _, line := range strings.SplitAfter(body, "\n") {
+		if state.fence == "" && isBoundary(line) && current.Len() > 0 {
+			blocks = append(blocks, segment{text: current.String(), state: start})
+			current.Reset()
+			start = state
+		}
+		current.WriteString(line)
+		state = state.advance(line)
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, segment{text: current.String(), state: start})
+	}
+	return blocks, state
+}
+
+func splitLines(block segment) []segment {
+	var result []segment
+	state := block.state
+	for _, line := range strings.SplitAfter(block.text, "\n") {
+		if line == "" {
+			continue
+		}
+		result = append(result, segment{text: line, state: state})
+		state = state.advance(line)
+	}
+	return result
+}
+
+func isBoundary(line string) bool {
+	return strings.HasPrefix(line, "### ") || strings.HasPrefix(line, "#### ") || strings.HasPrefix(line, "<details") || strings.TrimSpace(line) == "---"
+}
+
+func runeBoundary(s string, n int) int {
+	for n > 0 && n < len(s) && !isRuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,100 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitComment(t *testing.T) {
+	comment := strings.Join([]string{
+		"## Terraform Plan Summary",
+		"### Plan: a",
+		"",
+		"<details>",
+		"<summary>Changes</summary>",
+		"",
+		"#### `aws_instance.a`",
+		"```diff",
+		"-ami = \"one\"",
+		"+ami = \"two\"",
+		"```",
+		"",
+		"#### `aws_instance.b`",
+		"```diff",
+		"+ami = \"three\"",
+		"```",
+		"",
+		"</details>",
+		"",
+	}, "\n")
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "fits",
+			limit: len(comment),
+			want:  []string{comment},
+		},
+		{
+			name:  "cut between resources",
+			limit: 160,
+			want: []string{
+				"## Terraform Plan Summary\n### Plan: a\n\n<details>\n<summary>Changes</summary>\n\n#### `aws_instance.a`\n```diff\n-ami = \"one\"\n+ami = \"two\"\n```\n\n\n</details>\n",
+				continuedSummary + "#### `aws_instance.b`\n```diff\n+ami = \"three\"\n```\n\n</details>\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitComment(comment, tt.limit)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SplitComment() mismatch (-want +got):\n%s", diff)
+			}
+			for i, part := range got {
+				if len(part) > tt.limit {
+					t.Errorf("part %d is %d bytes, over the limit of %d", i+1, len(part), tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitCommentLongDiff(t *testing.T) {
+	var diff strings.Builder
+	for i := range 50 {
+		fmt.Fprintf(&diff, "+tag_%02d = \"value\"\n", i)
+	}
+	comment := "### Plan: a\n<details>\n<summary>Changes</summary>\n\n#### `aws_instance.a`\n```diff\n" + diff.String() + "```\n\n</details>\n"
+
+	const limit = 300
+	parts := SplitComment(comment, limit)
+	if len(parts) < 2 {
+		t.Fatalf("SplitComment() returned %d parts, want several", len(parts))
+	}
+
+	var joined strings.Builder
+	for i, part := range parts {
+		if len(part) > limit {
+			t.Errorf("part %d is %d bytes, over the limit of %d", i+1, len(part), limit)
+		}
+		if fences := strings.Count(part, "```"); fences%2 != 0 {
+			t.Errorf("part %d has an unclosed code fence:\n%s", i+1, part)
+		}
+		if opened, closed := strings.Count(part, "<details>"), strings.Count(part, "</details>"); opened != closed {
+			t.Errorf("part %d opens %d <details> blocks but closes %d", i+1, opened, closed)
+		}
+		joined.WriteString(part)
+	}
+	for i := range 50 {
+		if line := fmt.Sprintf("+tag_%02d = \"value\"\n", i); !strings.Contains(joined.String(), line) {
+			t.Errorf("parts lost diff line %q", line)
+		}
+	}
+}
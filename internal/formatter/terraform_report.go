@@ -0,0 +1,35 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// TerraformReport is the artifact read by GitLab's merge request widget
+// through artifacts:reports:terraform. GitLab adds the job name and link
+// itself. Recreated resources count as both a creation and a deletion, as in
+// GitLab's own gitlab-terraform wrapper.
+type TerraformReport struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
+}
+
+func NewTerraformReport(planData *terraform.PlanData) TerraformReport {
+	return TerraformReport{
+		Create: planData.Summary.Create + planData.Summary.Recreate,
+		Update: planData.Summary.Update,
+		Delete: planData.Summary.Delete + planData.Summary.Recreate,
+	}
+}
+
+// FormatTerraformReport renders the GitLab Terraform report of one plan.
+func FormatTerraformReport(planData *terraform.PlanData) (string, error) {
+	content, err := json.Marshal(NewTerraformReport(planData))
+	if err != nil {
+		return "", fmt.Errorf("error encoding terraform report: %w", err)
+	}
+	return string(content) + "\n", nil
+}
@@ -0,0 +1,22 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/templates"
+)
+
+var textTmpl = template.Must(template.New("plan.txt.tmpl").Funcs(funcMap).Parse(templates.TextTemplateContent))
+
+// FormatText renders plan data as plain text, suitable for job logs.
+func FormatText(multiPlanData *terraform.MultiPlanData) (string, error) {
+	var builder strings.Builder
+	if err := textTmpl.Execute(&builder, multiPlanData); err != nil {
+		return "", fmt.Errorf("error executing text template: %w", err)
+	}
+
+	return builder.String(), nil
+}
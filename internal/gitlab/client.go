@@ -1,10 +1,14 @@
 package gitlab
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,10 +19,25 @@ import (
 	"gitlab-terraform-mr-commenter/internal/types"
 )
 
+// Note visibilities and styles accepted in the configuration.
+const (
+	VisibilityInternal = "internal"
+	VisibilityPublic   = "public"
+	StyleNote          = "note"
+	StyleDiscussion    = "discussion"
+)
+
 type Client struct {
 	client    *gitlab.Client
 	projectID string
 	mrID      int64
+	// internal and discussion tell how plan notes are posted.
+	internal   bool
+	discussion bool
+	// namespace scopes the notes and discussions of this commenter, so that
+	// several commenters can share a merge request.
+	namespace  string
+	noteMarker string
 }
 
 func New(cfg *config.Config) (*Client, error) {
@@ -29,9 +48,13 @@ func New(cfg *config.Config) (*Client, error) {
 	}
 
 	return &Client{
-		client:    client,
-		projectID: cfg.ProjectID,
-		mrID:      cfg.MergeRequestID,
+		client:     client,
+		projectID:  cfg.ProjectID,
+		mrID:       cfg.MergeRequestID,
+		internal:   cfg.NoteVisibility == VisibilityInternal,
+		discussion: cfg.NoteStyle == StyleDiscussion,
+		namespace:  cfg.Namespace,
+		noteMarker: fmt.Sprintf(constants.NamespacedNoteMarkerFormat, cfg.Namespace),
 	}, nil
 }
 
@@ -56,41 +79,346 @@ func (c *Client) ValidateAccess(ctx context.Context) error {
 	return nil
 }
 
-func (c *Client) CreateNote(ctx context.Context, body string) error {
+// CreateNote posts a plan note with the configured visibility, as a
+// resolvable discussion when the discussion style is configured, and returns
+// its ID.
+func (c *Client) CreateNote(ctx context.Context, body string) (int64, error) {
+	if c.discussion {
+		opts := &gitlab.CreateMergeRequestDiscussionOptions{Body: &body}
+		discussion, _, err := c.client.Discussions.CreateMergeRequestDiscussion(c.projectID, c.mrID, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to create MR discussion: %w", err)
+		}
+		if len(discussion.Notes) == 0 {
+			return 0, fmt.Errorf("created MR discussion %s has no note", discussion.ID)
+		}
+		return discussion.Notes[0].ID, nil
+	}
+
 	note := &gitlab.CreateMergeRequestNoteOptions{
 		Body:     &body,
-		Internal: gitlab.Ptr(true),
+		Internal: gitlab.Ptr(c.internal),
+	}
+
+	created, _, err := c.client.Notes.CreateMergeRequestNote(c.projectID, c.mrID, note, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create MR note: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// FindPlanNotes returns the notes posted by a previous run in the client's
+// namespace, whatever their visibility and style, ordered by their part
+// number. Notes from before comments were split count as part 1. Without
+// such notes, the notes posted before markers were namespaced are returned,
+// to be taken over by this namespace.
+//
+// Notes are listed newest first, stopping at the page where the newest series
+// of parts is complete. Other notes with the marker, left by runs that missed
+// the existing comment, follow the series as duplicates; once one is found,
+// every page is listed so that older duplicates are found too.
+func (c *Client) FindPlanNotes(ctx context.Context) ([]*types.MRNote, error) {
+	opts := &gitlab.ListMergeRequestNotesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		OrderBy:     gitlab.Ptr("created_at"),
+		Sort:        gitlab.Ptr("desc"),
+	}
+	var series, legacy noteSeries
+	for {
+		notes, resp, err := c.client.Notes.ListMergeRequestNotes(c.projectID, c.mrID, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list MR notes: %w", err)
+		}
+		for _, note := range notes {
+			if note.System {
+				continue
+			}
+			switch {
+			case strings.Contains(note.Body, c.noteMarker):
+				series.add(c.planNote(note))
+			case strings.Contains(note.Body, constants.NoteMarker):
+				legacy.add(c.planNote(note))
+			}
+		}
+		if (series.complete() && len(series.duplicates) == 0) || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if series.parts == nil {
+		return legacy.notes(), nil
+	}
+	return series.notes(), nil
+}
+
+// GetPlanNotes returns the plan notes with the given IDs, ordered by their
+// part number, or nil when any of them is gone or no longer a plan note.
+func (c *Client) GetPlanNotes(ctx context.Context, noteIDs []int64) ([]*types.MRNote, error) {
+	var series noteSeries
+	for _, noteID := range noteIDs {
+		note, _, err := c.client.Notes.GetMergeRequestNote(c.projectID, c.mrID, noteID, gitlab.WithContext(ctx))
+		if errors.Is(err, gitlab.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MR note %d: %w", noteID, err)
+		}
+		if note.System || !strings.Contains(note.Body, c.noteMarker) {
+			return nil, nil
+		}
+		series.add(c.planNote(note))
+	}
+	if !series.complete() || len(series.duplicates) > 0 {
+		return nil, nil
+	}
+	return series.notes(), nil
+}
+
+// planNote reads a plan note found on the merge request. A standalone note
+// turns into a discussion note once someone replies to it, so a discussion
+// note is never taken for a restyled one: posting it again would orphan the
+// replies.
+func (c *Client) planNote(note *gitlab.Note) *types.MRNote {
+	part, total := notePart(note.Body)
+	return &types.MRNote{
+		ID:           note.ID,
+		Body:         note.Body,
+		Exists:       true,
+		Part:         part,
+		Parts:        total,
+		StyleChanged: note.Internal != c.internal || (c.discussion && note.Type != gitlab.DiscussionNote),
+	}
+}
+
+// noteSeries collects plan notes, newest first, into the series of parts
+// posted by the latest run. The newest note tells how many parts the series
+// has; older notes for a part already found are duplicates.
+type noteSeries struct {
+	parts      map[int]*types.MRNote
+	total      int
+	duplicates []*types.MRNote
+}
+
+func (s *noteSeries) add(note *types.MRNote) {
+	if s.parts == nil {
+		s.parts = make(map[int]*types.MRNote)
+		s.total = note.Parts
 	}
+	if _, found := s.parts[note.Part]; found || note.Part > s.total {
+		note.Duplicate = true
+		s.duplicates = append(s.duplicates, note)
+		return
+	}
+	s.parts[note.Part] = note
+}
+
+func (s *noteSeries) complete() bool {
+	return s.parts != nil && len(s.parts) == s.total
+}
+
+func (s *noteSeries) notes() []*types.MRNote {
+	result := make([]*types.MRNote, 0, len(s.parts)+len(s.duplicates))
+	for _, note := range s.parts {
+		result = append(result, note)
+	}
+	slices.SortFunc(result, func(a, b *types.MRNote) int {
+		return cmp.Compare(a.Part, b.Part)
+	})
+	return append(result, s.duplicates...)
+}
+
+// notePart returns the part number of a note and the number of parts in its
+// series.
+func notePart(body string) (int, int) {
+	var part, total int
+	for _, line := range strings.SplitN(body, "\n", 3) {
+		if _, err := fmt.Sscanf(line, constants.PartMarkerFormat, &part, &total); err == nil {
+			return part, total
+		}
+	}
+	return 1, 1
+}
 
-	_, _, err := c.client.Notes.CreateMergeRequestNote(c.projectID, c.mrID, note, gitlab.WithContext(ctx))
+func (c *Client) DeleteNote(ctx context.Context, noteID int64) error {
+	_, err := c.client.Notes.DeleteMergeRequestNote(c.projectID, c.mrID, noteID, gitlab.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to create MR note: %w", err)
+		return fmt.Errorf("failed to delete MR note %d: %w", noteID, err)
 	}
 
 	return nil
 }
 
-func (c *Client) FindExistingPlanNote(ctx context.Context) (*types.MRNote, error) {
-	notes, _, err := c.client.Notes.ListMergeRequestNotes(c.projectID, c.mrID, nil, gitlab.WithContext(ctx))
+// UploadFile uploads a file to the project and returns its URL, relative to
+// the project, for use in notes.
+func (c *Client) UploadFile(ctx context.Context, filename string, content []byte) (string, error) {
+	file, _, err := c.client.ProjectMarkdownUploads.UploadProjectMarkdown(c.projectID, bytes.NewReader(content), filename, gitlab.WithContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("failed to list MR notes: %w", err)
+		return "", fmt.Errorf("failed to upload %s: %w", filename, err)
 	}
 
-	for _, note := range notes {
-		if !note.Internal {
-			continue
+	return file.URL, nil
+}
+
+// MergeRequestChanges returns the merge request's web URL, diff refs and the
+// diffs of every file it changes.
+func (c *Client) MergeRequestChanges(ctx context.Context) (*types.MRChanges, error) {
+	mr, _, err := c.client.MergeRequests.GetMergeRequest(c.projectID, c.mrID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request %d: %w", c.mrID, err)
+	}
+
+	changes := &types.MRChanges{
+		WebURL:   mr.WebURL,
+		BaseSHA:  mr.DiffRefs.BaseSha,
+		StartSHA: mr.DiffRefs.StartSha,
+		HeadSHA:  mr.DiffRefs.HeadSha,
+	}
+	opts := &gitlab.ListMergeRequestDiffsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		diffs, resp, err := c.client.MergeRequests.ListMergeRequestDiffs(c.projectID, c.mrID, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge request %d diffs: %w", c.mrID, err)
+		}
+		for _, diff := range diffs {
+			changes.Files = append(changes.Files, &types.ChangedFile{
+				OldPath: diff.OldPath,
+				NewPath: diff.NewPath,
+				Diff:    diff.Diff,
+				Deleted: diff.DeletedFile,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return changes, nil
+}
+
+// FindDiscussions returns the discussions opened by previous runs in the
+// client's namespace, and those opened before discussion keys were
+// namespaced.
+func (c *Client) FindDiscussions(ctx context.Context) ([]*types.MRDiscussion, error) {
+	var result []*types.MRDiscussion
+	opts := &gitlab.ListMergeRequestDiscussionsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	for {
+		discussions, resp, err := c.client.Discussions.ListMergeRequestDiscussions(c.projectID, c.mrID, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list MR discussions: %w", err)
+		}
+		for _, discussion := range discussions {
+			if len(discussion.Notes) == 0 {
+				continue
+			}
+			note := discussion.Notes[0]
+			key, ok := c.discussionKey(note.Body)
+			if !ok {
+				continue
+			}
+			found := &types.MRDiscussion{
+				ID:       discussion.ID,
+				NoteID:   note.ID,
+				Body:     note.Body,
+				Key:      key,
+				Resolved: note.Resolved,
+				Replied:  len(discussion.Notes) > 1,
+			}
+			if note.Position != nil {
+				found.Position = &types.DiffPosition{
+					BaseSHA:  note.Position.BaseSHA,
+					StartSHA: note.Position.StartSHA,
+					HeadSHA:  note.Position.HeadSHA,
+					OldPath:  note.Position.OldPath,
+					NewPath:  note.Position.NewPath,
+					OldLine:  int(note.Position.OldLine),
+					NewLine:  int(note.Position.NewLine),
+				}
+			}
+			result = append(result, found)
 		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
 
-		if strings.Contains(note.Body, constants.NoteMarker) {
-			return &types.MRNote{
-				ID:     note.ID,
-				Body:   note.Body,
-				Exists: true,
-			}, nil
+// discussionKey returns the key recorded in the marker of a discussion, without
+// its namespace. Keys of other namespaces are not reported, nor are those of
+// discussions opened before markers were namespaced: any commenter could have
+// opened them, so none of them takes them over.
+func (c *Client) discussionKey(body string) (string, bool) {
+	marker, _, _ := strings.Cut(body, "\n")
+	var key string
+	if _, err := fmt.Sscanf(marker, constants.DiscussionMarkerFormat, &key); err != nil {
+		return "", false
+	}
+	namespace, key, namespaced := strings.Cut(key, "/")
+	return key, namespaced && namespace == c.namespace
+}
+
+// CreateDiscussion opens a discussion, on a line of the diff when position is
+// set.
+func (c *Client) CreateDiscussion(ctx context.Context, body string, position *types.DiffPosition) error {
+	opts := &gitlab.CreateMergeRequestDiscussionOptions{Body: &body}
+	if position != nil {
+		opts.Position = &gitlab.PositionOptions{
+			BaseSHA:      &position.BaseSHA,
+			StartSHA:     &position.StartSHA,
+			HeadSHA:      &position.HeadSHA,
+			PositionType: gitlab.Ptr("text"),
+			OldPath:      &position.OldPath,
+			NewPath:      &position.NewPath,
+		}
+		if position.NewLine > 0 {
+			opts.Position.NewLine = gitlab.Ptr(int64(position.NewLine))
+		}
+		if position.OldLine > 0 {
+			opts.Position.OldLine = gitlab.Ptr(int64(position.OldLine))
 		}
 	}
 
-	return &types.MRNote{Exists: false}, nil
+	_, _, err := c.client.Discussions.CreateMergeRequestDiscussion(c.projectID, c.mrID, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create MR discussion: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateDiscussion replaces the body of the first note of a discussion.
+func (c *Client) UpdateDiscussion(ctx context.Context, discussion *types.MRDiscussion, body string) error {
+	opts := &gitlab.UpdateMergeRequestDiscussionNoteOptions{Body: &body}
+	_, _, err := c.client.Discussions.UpdateMergeRequestDiscussionNote(c.projectID, c.mrID, discussion.ID, discussion.NoteID, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update MR discussion %s: %w", discussion.ID, err)
+	}
+
+	return nil
+}
+
+// ResolveDiscussion resolves or reopens a discussion.
+func (c *Client) ResolveDiscussion(ctx context.Context, discussion *types.MRDiscussion, resolved bool) error {
+	opts := &gitlab.ResolveMergeRequestDiscussionOptions{Resolved: &resolved}
+	_, _, err := c.client.Discussions.ResolveMergeRequestDiscussion(c.projectID, c.mrID, discussion.ID, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to resolve MR discussion %s: %w", discussion.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteDiscussion deletes the first note of a discussion, which removes the
+// discussion unless others have replied to it.
+func (c *Client) DeleteDiscussion(ctx context.Context, discussion *types.MRDiscussion) error {
+	_, err := c.client.Discussions.DeleteMergeRequestDiscussionNote(c.projectID, c.mrID, discussion.ID, discussion.NoteID, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete MR discussion %s: %w", discussion.ID, err)
+	}
+
+	return nil
 }
 
 func (c *Client) UpdateNote(ctx context.Context, noteID int64, body string) error {
@@ -0,0 +1,326 @@
+package gitlab
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	gocmp "github.com/google/go-cmp/cmp"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"gitlab-terraform-mr-commenter/internal/config"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestPlanNoteStyleChanged(t *testing.T) {
+	internalNote := &gitlab.Note{ID: 1, Internal: true, Type: gitlab.GenericNote}
+	publicNote := &gitlab.Note{ID: 2, Type: gitlab.GenericNote}
+	discussionNote := &gitlab.Note{ID: 3, Type: gitlab.DiscussionNote}
+
+	tests := []struct {
+		name   string
+		client *Client
+		note   *gitlab.Note
+		want   bool
+	}{
+		{name: "internal note, internal configured", client: &Client{internal: true}, note: internalNote, want: false},
+		{name: "internal note, public configured", client: &Client{}, note: internalNote, want: true},
+		{name: "public note, internal configured", client: &Client{internal: true}, note: publicNote, want: true},
+		{name: "public note, public configured", client: &Client{}, note: publicNote, want: false},
+		{name: "discussion, discussion configured", client: &Client{discussion: true}, note: discussionNote, want: false},
+		{name: "discussion or replied note, note configured", client: &Client{}, note: discussionNote, want: false},
+		{name: "note, discussion configured", client: &Client{discussion: true}, note: publicNote, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.client.planNote(tt.note).StyleChanged; got != tt.want {
+				t.Errorf("planNote().StyleChanged = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotePart(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantPart  int
+		wantTotal int
+	}{
+		{name: "single note", body: "<!-- gitlab-terraform-mr-commenter namespace prod -->\nbody", wantPart: 1, wantTotal: 1},
+		{name: "part of a series", body: "<!-- gitlab-terraform-mr-commenter namespace prod -->\n<!-- gitlab-terraform-mr-commenter part 2/3 -->\nbody", wantPart: 2, wantTotal: 3},
+		{name: "legacy note", body: "<!-- gitlab-terraform-mr-commenter -->\nbody", wantPart: 1, wantTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part, total := notePart(tt.body)
+			if part != tt.wantPart || total != tt.wantTotal {
+				t.Errorf("notePart() = %d, %d, want %d, %d", part, total, tt.wantPart, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestNoteSeries(t *testing.T) {
+	note := func(id int64, part, parts int) *types.MRNote {
+		return &types.MRNote{ID: id, Part: part, Parts: parts}
+	}
+
+	tests := []struct {
+		name           string
+		newestFirst    []*types.MRNote
+		wantComplete   bool
+		wantIDs        []int64
+		wantDuplicates []int64
+	}{
+		{
+			name: "no notes",
+		},
+		{
+			name:         "single note",
+			newestFirst:  []*types.MRNote{note(5, 1, 1)},
+			wantComplete: true,
+			wantIDs:      []int64{5},
+		},
+		{
+			name:         "series sorted by part",
+			newestFirst:  []*types.MRNote{note(9, 3, 3), note(8, 2, 3), note(7, 1, 3)},
+			wantComplete: true,
+			wantIDs:      []int64{7, 8, 9},
+		},
+		{
+			name:        "incomplete series",
+			newestFirst: []*types.MRNote{note(9, 2, 2)},
+			wantIDs:     []int64{9},
+		},
+		{
+			name:           "older notes are duplicates",
+			newestFirst:    []*types.MRNote{note(9, 2, 2), note(8, 1, 2), note(5, 1, 1), note(3, 2, 2)},
+			wantComplete:   true,
+			wantIDs:        []int64{8, 9},
+			wantDuplicates: []int64{5, 3},
+		},
+		{
+			name:           "parts beyond the newest series are duplicates",
+			newestFirst:    []*types.MRNote{note(9, 1, 1), note(4, 2, 2)},
+			wantComplete:   true,
+			wantIDs:        []int64{9},
+			wantDuplicates: []int64{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var series noteSeries
+			for _, note := range tt.newestFirst {
+				series.add(note)
+			}
+			if got := series.complete(); got != tt.wantComplete {
+				t.Errorf("complete() = %t, want %t", got, tt.wantComplete)
+			}
+			var ids, duplicates []int64
+			for _, note := range series.notes() {
+				if note.Duplicate {
+					duplicates = append(duplicates, note.ID)
+				} else {
+					ids = append(ids, note.ID)
+				}
+			}
+			if diff := gocmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("notes() series mismatch (-want +got):\n%s", diff)
+			}
+			if diff := gocmp.Diff(tt.wantDuplicates, duplicates); diff != "" {
+				t.Errorf("notes() duplicates mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// notesServer serves pages of merge request notes, newest first, and counts
+// the pages requested.
+func notesServer(t *testing.T, pages [][]*gitlab.Note, requests *int) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/merge_requests/1/notes") {
+			http.NotFound(w, r)
+			return
+		}
+		*requests++
+		page, _ := strconv.Atoi(cmp.Or(r.URL.Query().Get("page"), "1"))
+		if r.URL.Query().Get("sort") != "desc" || page < 1 || page > len(pages) {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if page < len(pages) {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pages[page-1])
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New(&config.Config{
+		GitlabToken:    "token",
+		GitlabURL:      server.URL,
+		ProjectID:      "1",
+		MergeRequestID: 1,
+		NoteVisibility: VisibilityInternal,
+		NoteStyle:      StyleNote,
+		Namespace:      "prod",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return client
+}
+
+func TestFindPlanNotes(t *testing.T) {
+	planNote := func(id int64, marker string) *gitlab.Note {
+		return &gitlab.Note{ID: id, Body: marker + "\nbody", Internal: true}
+	}
+	const (
+		prod   = "<!-- gitlab-terraform-mr-commenter namespace prod -->"
+		other  = "<!-- gitlab-terraform-mr-commenter namespace other -->"
+		legacy = "<!-- gitlab-terraform-mr-commenter -->"
+	)
+	chatter := &gitlab.Note{ID: 1, Body: "LGTM"}
+
+	tests := []struct {
+		name           string
+		pages          [][]*gitlab.Note
+		wantIDs        []int64
+		wantDuplicates []int64
+		wantRequests   int
+	}{
+		{
+			name:         "stops at the page completing the series",
+			pages:        [][]*gitlab.Note{{chatter, planNote(30, prod)}, {planNote(10, prod)}},
+			wantIDs:      []int64{30},
+			wantRequests: 1,
+		},
+		{
+			name:           "keeps listing once duplicates are found",
+			pages:          [][]*gitlab.Note{{planNote(30, prod), planNote(20, prod)}, {chatter}, {planNote(10, prod)}},
+			wantIDs:        []int64{30},
+			wantDuplicates: []int64{20, 10},
+			wantRequests:   3,
+		},
+		{
+			name:         "skips system notes",
+			pages:        [][]*gitlab.Note{{{ID: 40, Body: prod, System: true}, planNote(30, prod)}},
+			wantIDs:      []int64{30},
+			wantRequests: 1,
+		},
+		{
+			name:         "ignores other namespaces",
+			pages:        [][]*gitlab.Note{{planNote(40, other)}, {planNote(30, prod)}},
+			wantIDs:      []int64{30},
+			wantRequests: 2,
+		},
+		{
+			name:         "takes over legacy notes without a namespaced one",
+			pages:        [][]*gitlab.Note{{planNote(40, other), chatter}, {planNote(20, legacy)}},
+			wantIDs:      []int64{20},
+			wantRequests: 2,
+		},
+		{
+			name:         "prefers the namespaced note to legacy ones",
+			pages:        [][]*gitlab.Note{{planNote(20, legacy)}, {planNote(10, prod)}},
+			wantIDs:      []int64{10},
+			wantRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			client := notesServer(t, tt.pages, &requests)
+			notes, err := client.FindPlanNotes(context.Background())
+			if err != nil {
+				t.Fatalf("FindPlanNotes() error = %v", err)
+			}
+			var ids, duplicates []int64
+			for _, note := range notes {
+				if note.Duplicate {
+					duplicates = append(duplicates, note.ID)
+				} else {
+					ids = append(ids, note.ID)
+				}
+			}
+			if diff := gocmp.Diff(tt.wantIDs, ids); diff != "" {
+				t.Errorf("FindPlanNotes() series mismatch (-want +got):\n%s", diff)
+			}
+			if diff := gocmp.Diff(tt.wantDuplicates, duplicates); diff != "" {
+				t.Errorf("FindPlanNotes() duplicates mismatch (-want +got):\n%s", diff)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("FindPlanNotes() listed %d pages, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestFindPlanNotesWithReply(t *testing.T) {
+	// GitLab lists a standalone note someone replied to as a discussion note.
+	replied := &gitlab.Note{
+		ID:       30,
+		Body:     "<!-- gitlab-terraform-mr-commenter namespace prod -->\nbody",
+		Internal: true,
+		Type:     gitlab.DiscussionNote,
+	}
+	var requests int
+	client := notesServer(t, [][]*gitlab.Note{{replied}}, &requests)
+	notes, err := client.FindPlanNotes(context.Background())
+	if err != nil {
+		t.Fatalf("FindPlanNotes() error = %v", err)
+	}
+	if len(notes) != 1 || notes[0].StyleChanged {
+		t.Errorf("FindPlanNotes() = %+v, want note 30 kept in its style", notes)
+	}
+}
+
+func TestDiscussionKey(t *testing.T) {
+	client := &Client{namespace: "prod"}
+
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "own namespace",
+			body:   "<!-- gitlab-terraform-mr-commenter discussion prod/destroy -->\ntext",
+			want:   "destroy",
+			wantOK: true,
+		},
+		{
+			name: "other namespace",
+			body: "<!-- gitlab-terraform-mr-commenter discussion other/destroy -->\ntext",
+		},
+		{
+			name: "not namespaced",
+			body: "<!-- gitlab-terraform-mr-commenter discussion destroy -->\ntext",
+		},
+		{
+			name: "no marker",
+			body: "LGTM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := client.discussionKey(tt.body)
+			if ok != tt.wantOK || (ok && key != tt.want) {
+				t.Errorf("discussionKey() = %q, %v, want %q, %v", key, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package output
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatMarkdown    = "md"
+	FormatJSON        = "json"
+	FormatText        = "text"
+	FormatHTML        = "html"
+	FormatTerraform   = "terraform"
+	FormatCodeQuality = "codequality"
+	FormatJUnit       = "junit"
+)
+
+var formatNames = map[string]string{
+	FormatMarkdown:    "Markdown",
+	FormatJSON:        "JSON",
+	FormatText:        "Text",
+	FormatHTML:        "HTML",
+	FormatTerraform:   "Terraform report",
+	FormatCodeQuality: "Code Quality report",
+	FormatJUnit:       "JUnit report",
+}
+
+// Target is one requested output: a format and the file it is written to.
+type Target struct {
+	Format string
+	Path   string
+}
+
+// ParseTarget parses a "format:path" output value. A value without a known
+// format prefix is a path for the Markdown comment, as in earlier releases.
+func ParseTarget(value string) (Target, error) {
+	if format, path, ok := strings.Cut(value, ":"); ok {
+		if _, known := formatNames[format]; known {
+			if path == "" {
+				return Target{}, fmt.Errorf("missing path in output %q", value)
+			}
+			return Target{Format: format, Path: path}, nil
+		}
+	}
+	if value == "" {
+		return Target{}, fmt.Errorf("empty output value")
+	}
+	return Target{Format: FormatMarkdown, Path: value}, nil
+}
+
+func (t Target) String() string {
+	return t.Format + ":" + t.Path
+}
+
+// ForPlan returns the target for one plan's file when a format writes a file
+// per plan: the plan name is inserted before the extension, so that
+// "tfplan.json" becomes "tfplan.production.json". Slashes in the name are
+// replaced, so the file stays next to the others. Stdout is shared.
+func (t Target) ForPlan(planName string) Target {
+	if t.Path == stdoutFileIndicator {
+		return t
+	}
+	ext := filepath.Ext(t.Path)
+	t.Path = strings.TrimSuffix(t.Path, ext) + "." + strings.ReplaceAll(planName, "/", "-") + ext
+	return t
+}
@@ -0,0 +1,52 @@
+package output
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Target
+		wantErr bool
+	}{
+		{value: "comment.md", want: Target{Format: FormatMarkdown, Path: "comment.md"}},
+		{value: "-", want: Target{Format: FormatMarkdown, Path: "-"}},
+		{value: "json:summary.json", want: Target{Format: FormatJSON, Path: "summary.json"}},
+		{value: "html:out/report.html", want: Target{Format: FormatHTML, Path: "out/report.html"}},
+		{value: "text:-", want: Target{Format: FormatText, Path: "-"}},
+		{value: "reports/a:b.md", want: Target{Format: FormatMarkdown, Path: "reports/a:b.md"}},
+		{value: "json:", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTarget(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTarget(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetForPlan(t *testing.T) {
+	tests := []struct {
+		path     string
+		planName string
+		want     string
+	}{
+		{path: "tfplan.json", planName: "prod", want: "tfplan.prod.json"},
+		{path: "reports/tfplan", planName: "prod", want: "reports/tfplan.prod"},
+		{path: "tfplan.json", planName: "eu/prod/plan", want: "tfplan.eu-prod-plan.json"},
+		{path: "-", planName: "prod", want: "-"},
+	}
+
+	for _, tt := range tests {
+		got := Target{Format: FormatTerraform, Path: tt.path}.ForPlan(tt.planName)
+		if got.Path != tt.want {
+			t.Errorf("ForPlan(%q) path = %q, want %q", tt.path, got.Path, tt.want)
+		}
+	}
+}
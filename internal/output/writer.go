@@ -27,10 +27,10 @@ func Write(content, filename string) (err error) {
 	return nil
 }
 
-func PrintSuccess(filename string) {
+func PrintSuccess(target Target) {
 	destination := "stdout"
-	if filename != stdoutFileIndicator {
-		destination = filename
+	if target.Path != stdoutFileIndicator {
+		destination = target.Path
 	}
-	fmt.Fprintf(os.Stderr, "Markdown output written to %s\n", destination)
+	fmt.Fprintf(os.Stderr, "%s output written to %s\n", formatNames[target.Format], destination)
 }
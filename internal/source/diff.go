@@ -0,0 +1,144 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// Diff holds, for every file a merge request changes, the lines of the new
+// version shown in the merge request diff, keyed by line number.
+type Diff map[string]map[int]DiffLine
+
+// DiffLine is a line shown in a merge request diff. OldLine is the line
+// GitLab pairs it with in the old version: the same line for context lines,
+// and the line the addition follows for added ones.
+type DiffLine struct {
+	OldLine int
+	Added   bool
+}
+
+// NewDiff parses the diffs of the files changed by a merge request. Deleted
+// files are left out, as nothing in them can be declared any more.
+func NewDiff(files []*types.ChangedFile) Diff {
+	diff := make(Diff)
+	for _, file := range files {
+		if !file.Deleted {
+			diff[file.NewPath] = diffLines(file.Diff)
+		}
+	}
+	return diff
+}
+
+// Position returns the line of a block a diff discussion should be anchored
+// to: the first line added within the block, or else its first line when the
+// diff shows it. It returns false when the block is not part of the diff.
+func (d Diff) Position(location *terraform.SourceLocation) (int, DiffLine, bool) {
+	lines, ok := d[location.File]
+	if !ok {
+		return 0, DiffLine{}, false
+	}
+	for number := location.Line; number <= location.EndLine; number++ {
+		if line, ok := lines[number]; ok && line.Added {
+			return number, line, true
+		}
+	}
+	if line, ok := lines[location.Line]; ok {
+		return location.Line, line, true
+	}
+	return 0, DiffLine{}, false
+}
+
+// RemovedBlock is the opening line of a resource or data block that a merge
+// request removes, in the old version of its file.
+type RemovedBlock struct {
+	OldPath string
+	NewPath string
+	OldLine int
+}
+
+// removedBlockRe matches the removed opening line of a resource or data block.
+var removedBlockRe = regexp.MustCompile(`^-\s*(resource|data)\s+"([^"]+)"\s+"([^"]+)"`)
+
+// RemovedBlocks finds the resource and data blocks whose opening line the
+// merge request removes, keyed by their address within their module, such as
+// aws_s3_bucket.logs or data.aws_ami.ubuntu. Blocks that are only moved are
+// found too; callers look here only for resources no longer declared.
+func RemovedBlocks(files []*types.ChangedFile) map[string][]RemovedBlock {
+	blocks := make(map[string][]RemovedBlock)
+	for _, file := range files {
+		var oldLine int
+		for _, line := range strings.Split(file.Diff, "\n") {
+			if strings.HasPrefix(line, "@@") {
+				var oldStart, newStart int
+				if _, err := fmt.Sscanf(hunkStarts(line), "-%d +%d", &oldStart, &newStart); err == nil {
+					oldLine = oldStart
+				}
+				continue
+			}
+			if oldLine == 0 {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(line, "-"):
+				if match := removedBlockRe.FindStringSubmatch(line); match != nil {
+					address := match[2] + "." + match[3]
+					if match[1] == "data" {
+						address = "data." + address
+					}
+					blocks[address] = append(blocks[address], RemovedBlock{OldPath: file.OldPath, NewPath: file.NewPath, OldLine: oldLine})
+				}
+				oldLine++
+			case strings.HasPrefix(line, " "):
+				oldLine++
+			}
+		}
+	}
+	return blocks
+}
+
+// diffLines maps the new line numbers shown in a unified diff to how GitLab
+// pairs them with the old version.
+func diffLines(diff string) map[int]DiffLine {
+	lines := make(map[int]DiffLine)
+	var oldLine, newLine int
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			var oldStart, newStart int
+			if _, err := fmt.Sscanf(hunkStarts(line), "-%d +%d", &oldStart, &newStart); err == nil {
+				oldLine, newLine = oldStart, newStart
+			}
+			continue
+		}
+		if newLine == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines[newLine] = DiffLine{OldLine: oldLine, Added: true}
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			oldLine++
+		case strings.HasPrefix(line, " "):
+			lines[newLine] = DiffLine{OldLine: oldLine}
+			oldLine++
+			newLine++
+		}
+	}
+	return lines
+}
+
+// hunkStarts reduces a hunk header such as "@@ -1,4 +1,6 @@ resource" to the
+// start lines "-1 +1".
+func hunkStarts(header string) string {
+	fields := strings.Fields(strings.TrimPrefix(header, "@@"))
+	if len(fields) < 2 {
+		return ""
+	}
+	oldStart, _, _ := strings.Cut(fields[0], ",")
+	newStart, _, _ := strings.Cut(fields[1], ",")
+	return oldStart + " " + newStart
+}
@@ -0,0 +1,89 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+func TestDiffPosition(t *testing.T) {
+	diff := NewDiff([]*types.ChangedFile{
+		{OldPath: "modules/network/subnets.tf", NewPath: "modules/network/subnets.tf", Diff: networkDiff},
+	})
+
+	tests := []struct {
+		name     string
+		location *terraform.SourceLocation
+		wantLine int
+		want     DiffLine
+		wantOK   bool
+	}{
+		{
+			name:     "first added line in block",
+			location: &terraform.SourceLocation{File: "modules/network/subnets.tf", Line: 5, EndLine: 9},
+			wantLine: 6,
+			want:     DiffLine{OldLine: 7, Added: true},
+			wantOK:   true,
+		},
+		{
+			name:     "unchanged block shown in diff",
+			location: &terraform.SourceLocation{File: "modules/network/subnets.tf", Line: 3, EndLine: 3},
+			wantLine: 3,
+			want:     DiffLine{OldLine: 3},
+			wantOK:   true,
+		},
+		{
+			name:     "block outside diff",
+			location: &terraform.SourceLocation{File: "modules/network/subnets.tf", Line: 1, EndLine: 2},
+		},
+		{
+			name:     "file not changed",
+			location: &terraform.SourceLocation{File: "main.tf", Line: 5, EndLine: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, got, ok := diff.Position(tt.location)
+			if ok != tt.wantOK || line != tt.wantLine || got != tt.want {
+				t.Errorf("Position() = %d, %+v, %t, want %d, %+v, %t", line, got, ok, tt.wantLine, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+const removalDiff = `@@ -1,9 +1,3 @@
+-resource "aws_s3_bucket" "logs" {
+-  bucket = "logs"
+-}
+-
+-data "aws_iam_policy_document" "logs" {
+-  statement {}
+-}
+ resource "aws_s3_bucket" "data" {
+   bucket = "data"
+ }`
+
+func TestRemovedBlocks(t *testing.T) {
+	got := RemovedBlocks([]*types.ChangedFile{
+		{OldPath: "storage.tf", NewPath: "storage.tf", Diff: removalDiff},
+		{OldPath: "modules/network/subnets.tf", NewPath: "modules/network/subnets.tf", Diff: networkDiff},
+		{OldPath: "legacy.tf", NewPath: "legacy.tf", Deleted: true, Diff: "@@ -1,2 +0,0 @@\n-resource \"aws_s3_bucket\" \"logs\" {\n-}"},
+	})
+
+	want := map[string][]RemovedBlock{
+		"aws_s3_bucket.logs": {
+			{OldPath: "storage.tf", NewPath: "storage.tf", OldLine: 1},
+			{OldPath: "legacy.tf", NewPath: "legacy.tf", OldLine: 1},
+		},
+		"data.aws_iam_policy_document.logs": {
+			{OldPath: "storage.tf", NewPath: "storage.tf", OldLine: 5},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RemovedBlocks() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,84 @@
+package source
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+// Linker builds links to source locations: into the merge request diff for
+// files the merge request changes, and to the file at the head commit for
+// the others.
+type Linker struct {
+	projectURL      string
+	headSHA         string
+	mergeRequestURL string
+	diff            Diff
+}
+
+// NewLinker returns a linker for the project at projectURL. Without a head
+// commit, only files changed by the merge request are linked; without a
+// merge request URL, only blobs are.
+func NewLinker(projectURL, headSHA, mergeRequestURL string, files []*types.ChangedFile) *Linker {
+	return &Linker{
+		projectURL:      strings.TrimSuffix(projectURL, "/"),
+		headSHA:         headSHA,
+		mergeRequestURL: strings.TrimSuffix(mergeRequestURL, "/"),
+		diff:            NewDiff(files),
+	}
+}
+
+// Link returns the URL of a source location, or "" when none can be built.
+func (l *Linker) Link(location *terraform.SourceLocation) string {
+	if lines, ok := l.diff[location.File]; ok && l.mergeRequestURL != "" {
+		anchor := fileHash(location.File)
+		if line, ok := lines[location.Line]; ok {
+			anchor = fmt.Sprintf("%s_%d_%d", anchor, line.OldLine, location.Line)
+		}
+		return l.mergeRequestURL + "/diffs#" + anchor
+	}
+	if l.projectURL == "" || l.headSHA == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/-/blob/%s/%s#L%d", l.projectURL, l.headSHA, escapePath(location.File), location.Line)
+}
+
+// LinkAll sets the URL of every source location attached to the plans.
+func (l *Linker) LinkAll(multiPlanData *terraform.MultiPlanData) {
+	for _, plan := range multiPlanData.Plans {
+		for _, resources := range [][]*terraform.ResourceData{
+			plan.Data.CreatedResources,
+			plan.Data.UpdatedResources,
+			plan.Data.RecreatedResources,
+			plan.Data.DeletedResources,
+		} {
+			for _, resource := range resources {
+				for _, r := range append([]*terraform.ResourceData{resource}, resource.Instances...) {
+					if r.Source != nil {
+						r.Source.URL = l.Link(r.Source)
+					}
+				}
+			}
+		}
+	}
+}
+
+// fileHash is the identifier GitLab uses for a file in merge request diff
+// anchors.
+func fileHash(path string) string {
+	sum := sha1.Sum([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,81 @@
+package source
+
+import (
+	"testing"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+	"gitlab-terraform-mr-commenter/internal/types"
+)
+
+const networkDiff = `@@ -3,6 +3,10 @@ variable "vpc_id" {
+ }
+ 
+ resource "aws_subnet" "private" {
+-  for_each = toset(["a"])
++  for_each = toset(["a", "b"])
+ 
+   vpc_id = var.vpc_id
+ }
++
++output "subnet_ids" {
++  value = values(aws_subnet.private)[*].id
++}`
+
+func TestLinkerLink(t *testing.T) {
+	linker := NewLinker(
+		"https://gitlab.example.com/infra/live",
+		"4f1c2d9",
+		"https://gitlab.example.com/infra/live/-/merge_requests/7",
+		[]*types.ChangedFile{
+			{OldPath: "modules/network/subnets.tf", NewPath: "modules/network/subnets.tf", Diff: networkDiff},
+			{OldPath: "old.tf", NewPath: "old.tf", Diff: "@@ -1,3 +0,0 @@\n-resource \"aws_vpc\" \"old\" {\n-}\n-", Deleted: true},
+		},
+	)
+	// SHA-1 of modules/network/subnets.tf.
+	fileHash := "dbb14ae225171c858a92a422427624a8d1b13a04"
+
+	tests := []struct {
+		name     string
+		location *terraform.SourceLocation
+		want     string
+	}{
+		{
+			name:     "unchanged file",
+			location: &terraform.SourceLocation{File: "main.tf", Line: 5},
+			want:     "https://gitlab.example.com/infra/live/-/blob/4f1c2d9/main.tf#L5",
+		},
+		{
+			name:     "context line in diff",
+			location: &terraform.SourceLocation{File: "modules/network/subnets.tf", Line: 5},
+			want:     "https://gitlab.example.com/infra/live/-/merge_requests/7/diffs#" + fileHash + "_5_5",
+		},
+		{
+			name:     "added line in diff",
+			location: &terraform.SourceLocation{File: "modules/network/subnets.tf", Line: 12},
+			want:     "https://gitlab.example.com/infra/live/-/merge_requests/7/diffs#" + fileHash + "_10_12",
+		},
+		{
+			name:     "line outside diff",
+			location: &terraform.SourceLocation{File: "modules/network/subnets.tf", Line: 1},
+			want:     "https://gitlab.example.com/infra/live/-/merge_requests/7/diffs#" + fileHash,
+		},
+		{
+			name:     "deleted file",
+			location: &terraform.SourceLocation{File: "old.tf", Line: 1},
+			want:     "https://gitlab.example.com/infra/live/-/blob/4f1c2d9/old.tf#L1",
+		},
+		{
+			name:     "escaped path",
+			location: &terraform.SourceLocation{File: "envs/prod eu/main.tf", Line: 2},
+			want:     "https://gitlab.example.com/infra/live/-/blob/4f1c2d9/envs/prod%20eu/main.tf#L2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linker.Link(tt.location); got != tt.want {
+				t.Errorf("Link() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
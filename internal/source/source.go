@@ -0,0 +1,143 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"gitlab-terraform-mr-commenter/internal/terraform"
+)
+
+// Index maps resource configuration addresses, such as
+// module.network.aws_subnet.private, to where they are declared.
+type Index map[string]*terraform.SourceLocation
+
+// Load parses the .tf files in dir and in the local modules it calls,
+// recording where every resource and data block is declared. File paths are
+// relative to root. A directory without .tf files yields an empty index.
+//
+// Files that cannot be read or parsed are skipped: the index of the other
+// files is returned together with an error listing them.
+func Load(dir, root string) (Index, error) {
+	index := make(Index)
+	loader := &loader{parser: hclparse.NewParser(), root: root, index: index, visited: make(map[string]bool)}
+	loader.loadModule(dir, "")
+	return index, errors.Join(loader.errs...)
+}
+
+// Attach sets the source location of every resource of the plan found in the
+// index, including the instances of grouped resources.
+func Attach(planData *terraform.PlanData, index Index) {
+	for _, resources := range [][]*terraform.ResourceData{
+		planData.CreatedResources,
+		planData.UpdatedResources,
+		planData.RecreatedResources,
+		planData.DeletedResources,
+	} {
+		for _, resource := range resources {
+			resource.Source = index[resource.ConfigAddress()]
+			for _, instance := range resource.Instances {
+				instance.Source = index[instance.ConfigAddress()]
+			}
+		}
+	}
+}
+
+type loader struct {
+	parser  *hclparse.Parser
+	root    string
+	index   Index
+	visited map[string]bool
+	errs    []error
+}
+
+func (l *loader) loadModule(dir, prefix string) {
+	if l.visited[prefix] {
+		return
+	}
+	l.visited[prefix] = true
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("error listing Terraform files in %s: %w", dir, err))
+		return
+	}
+	for _, file := range files {
+		if err := l.loadFile(dir, file, prefix); err != nil {
+			l.errs = append(l.errs, err)
+		}
+	}
+}
+
+func (l *loader) loadFile(dir, file, prefix string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", file, err)
+	}
+	parsed, diags := l.parser.ParseHCL(content, file)
+	if diags.HasErrors() {
+		return fmt.Errorf("error parsing %s: %w", file, diags)
+	}
+	body, ok := parsed.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	relative := file
+	if rel, err := filepath.Rel(l.root, file); err == nil {
+		relative = rel
+	}
+	relative = filepath.ToSlash(relative)
+
+	for _, block := range body.Blocks {
+		switch {
+		case block.Type == "resource" && len(block.Labels) == 2:
+			l.record(prefix+block.Labels[0]+"."+block.Labels[1], relative, block)
+		case block.Type == "data" && len(block.Labels) == 2:
+			l.record(prefix+"data."+block.Labels[0]+"."+block.Labels[1], relative, block)
+		case block.Type == "module" && len(block.Labels) == 1:
+			source, ok := localSource(block.Body)
+			if !ok {
+				continue
+			}
+			l.loadModule(filepath.Join(dir, source), prefix+"module."+block.Labels[0]+".")
+		}
+	}
+	return nil
+}
+
+func (l *loader) record(address, file string, block *hclsyntax.Block) {
+	if _, exists := l.index[address]; exists {
+		return
+	}
+	l.index[address] = &terraform.SourceLocation{
+		File:    file,
+		Line:    block.TypeRange.Start.Line,
+		EndLine: block.CloseBraceRange.End.Line,
+	}
+}
+
+// localSource returns the source of a module call when it is a local path;
+// registry and remote modules are not part of the repository.
+func localSource(body *hclsyntax.Body) (string, bool) {
+	attribute, ok := body.Attributes["source"]
+	if !ok {
+		return "", false
+	}
+	value, diags := attribute.Expr.Value(&hcl.EvalContext{})
+	if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return "", false
+	}
+	source := value.AsString()
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return "", false
+	}
+	return source, true
+}
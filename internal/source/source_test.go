@@ -0,0 +1,47 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	got, err := Load("testdata/config", "testdata")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := Index{
+		"aws_vpc.main":                          {File: "config/main.tf", Line: 5, EndLine: 7},
+		"data.aws_availability_zones.available": {File: "config/main.tf", Line: 9, EndLine: 9},
+		"module.network.aws_subnet.private":     {File: "config/modules/network/subnets.tf", Line: 5, EndLine: 9},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadWithoutConfiguration(t *testing.T) {
+	got, err := Load(t.TempDir(), ".")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Load() = %v, want an empty index", got)
+	}
+}
+
+func TestLoadInvalidConfiguration(t *testing.T) {
+	got, err := Load("testdata/invalid", "testdata")
+	if err == nil {
+		t.Error("Load() expected an error for invalid HCL")
+	}
+
+	want := Index{
+		"aws_s3_bucket.logs": {File: "invalid/valid.tf", Line: 1, EndLine: 3},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Load() should keep the valid files (-want +got):\n%s", diff)
+	}
+}
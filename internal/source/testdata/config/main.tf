@@ -0,0 +1,19 @@
+terraform {
+  required_version = ">= 1.5"
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+data "aws_availability_zones" "available" {}
+
+module "network" {
+  source = "./modules/network"
+  vpc_id = aws_vpc.main.id
+}
+
+module "registry" {
+  source  = "terraform-aws-modules/s3-bucket/aws"
+  version = "4.1.0"
+}
@@ -0,0 +1,94 @@
+package terraform
+
+import (
+	"slices"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// CheckResult is the outcome of one check block, precondition or
+// postcondition as evaluated during the plan.
+type CheckResult struct {
+	Address  string
+	Kind     string
+	Status   string
+	Problems []string
+}
+
+// collectChecks flattens the plan's check results to one entry per checked
+// object instance, falling back to the static address for checks that have
+// no instances.
+func collectChecks(checks []tfjson.CheckResultStatic) []*CheckResult {
+	var result []*CheckResult
+	for _, check := range checks {
+		if len(check.Instances) == 0 {
+			result = append(result, &CheckResult{
+				Address: check.Address.ToDisplay,
+				Kind:    string(check.Address.Kind),
+				Status:  string(check.Status),
+			})
+			continue
+		}
+		for _, instance := range check.Instances {
+			problems := make([]string, 0, len(instance.Problems))
+			for _, problem := range instance.Problems {
+				problems = append(problems, problem.Message)
+			}
+			result = append(result, &CheckResult{
+				Address:  instance.Address.ToDisplay,
+				Kind:     string(check.Address.Kind),
+				Status:   string(instance.Status),
+				Problems: problems,
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b *CheckResult) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return result
+}
+
+// DisallowedDestroys returns the recreated and deleted resource instances
+// whose address matches none of the allowed glob patterns.
+func (p *PlanData) DisallowedDestroys(allowed []string) []*ResourceData {
+	var result []*ResourceData
+	for _, resources := range [][]*ResourceData{p.RecreatedResources, p.DeletedResources} {
+		for _, resource := range resources {
+			for _, member := range resource.Members() {
+				if !matchesAny(member.Address, allowed) {
+					result = append(result, member)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// matchesAny reports whether address matches one of the patterns, where "*"
+// matches any run of characters, including dots and brackets.
+func matchesAny(address string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if globMatch(pattern, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(pattern, s string) bool {
+	prefix, rest, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	s = s[len(prefix):]
+	for i := 0; i <= len(s); i++ {
+		if globMatch(rest, s[i:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestCollectChecks(t *testing.T) {
+	checks := []tfjson.CheckResultStatic{
+		{
+			Address: tfjson.CheckStaticAddress{ToDisplay: "check.health", Kind: tfjson.CheckKindCheckBlock},
+			Status:  tfjson.CheckStatusFail,
+			Instances: []tfjson.CheckResultDynamic{{
+				Address:  tfjson.CheckDynamicAddress{ToDisplay: "check.health"},
+				Status:   tfjson.CheckStatusFail,
+				Problems: []tfjson.CheckResultProblem{{Message: "unhealthy"}},
+			}},
+		},
+		{
+			Address: tfjson.CheckStaticAddress{ToDisplay: "aws_instance.web", Kind: tfjson.CheckKindResource},
+			Status:  tfjson.CheckStatusUnknown,
+		},
+	}
+
+	want := []*CheckResult{
+		{Address: "aws_instance.web", Kind: "resource", Status: "unknown"},
+		{Address: "check.health", Kind: "check", Status: "fail", Problems: []string{"unhealthy"}},
+	}
+	if diff := cmp.Diff(want, collectChecks(checks)); diff != "" {
+		t.Errorf("collectChecks() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDisallowedDestroys(t *testing.T) {
+	planData := &PlanData{
+		UpdatedResources:   []*ResourceData{{Address: "aws_instance.web", Action: "update"}},
+		RecreatedResources: []*ResourceData{{Address: "aws_instance.db", Action: "recreate"}},
+		DeletedResources: []*ResourceData{
+			{Address: `module.legacy.aws_s3_bucket.logs["a"]`, Action: "delete"},
+			{Address: "aws_iam_user.u[*]", Action: "delete", Instances: []*ResourceData{
+				{Address: "aws_iam_user.u[3]", Action: "delete"},
+				{Address: "aws_iam_user.u[4]", Action: "delete"},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{name: "nothing allowed", want: []string{"aws_instance.db", `module.legacy.aws_s3_bucket.logs["a"]`, "aws_iam_user.u[3]", "aws_iam_user.u[4]"}},
+		{name: "module wildcard", allowed: []string{"module.legacy.*"}, want: []string{"aws_instance.db", "aws_iam_user.u[3]", "aws_iam_user.u[4]"}},
+		{name: "exact and index wildcard", allowed: []string{"aws_instance.db", "aws_iam_user.u[*]"}, want: []string{`module.legacy.aws_s3_bucket.logs["a"]`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, resource := range planData.DisallowedDestroys(tt.allowed) {
+				got = append(got, resource.Address)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DisallowedDestroys() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	DependentResource = "resource"
+	DependentOutput   = "output"
+)
+
+// Dependent is a resource or output whose configuration references a changed
+// resource, directly or through module inputs and outputs.
+type Dependent struct {
+	Address  string
+	Kind     string
+	Changing bool
+}
+
+var instanceKeyRe = regexp.MustCompile(`\[[^\]]*\]`)
+
+// referenceGraph maps a configuration node to the nodes whose expressions
+// reference it. Nodes are module-qualified configuration addresses without
+// instance keys: resources ("module.net.aws_vpc.main"), outputs
+// ("module.net.output.vpc_id") and module input variables
+// ("module.net.var.cidr").
+type referenceGraph map[string][]string
+
+func buildReferenceGraph(config *tfjson.Config) referenceGraph {
+	graph := make(referenceGraph)
+	if config == nil || config.RootModule == nil {
+		return graph
+	}
+	graph.addModule(config.RootModule, "")
+	return graph
+}
+
+func (g referenceGraph) addModule(module *tfjson.ConfigModule, prefix string) {
+	for _, resource := range module.Resources {
+		node := prefix + resource.Address
+		refs := expressionReferences(resource.Expressions)
+		for _, expr := range []*tfjson.Expression{resource.CountExpression, resource.ForEachExpression} {
+			if expr != nil {
+				refs = append(refs, expr.References...)
+			}
+		}
+		refs = append(refs, resource.DependsOn...)
+		g.addReferences(node, refs, prefix)
+	}
+
+	for name, output := range module.Outputs {
+		node := prefix + "output." + name
+		var refs []string
+		if output.Expression != nil {
+			refs = append(refs, output.Expression.References...)
+		}
+		refs = append(refs, output.DependsOn...)
+		g.addReferences(node, refs, prefix)
+	}
+
+	for name, call := range module.ModuleCalls {
+		childPrefix := prefix + "module." + name + "."
+		for argument, expr := range call.Expressions {
+			g.addReferences(childPrefix+"var."+argument, expressionReferences(map[string]*tfjson.Expression{argument: expr}), prefix)
+		}
+		if call.Module != nil {
+			g.addModule(call.Module, childPrefix)
+		}
+	}
+}
+
+func (g referenceGraph) addReferences(node string, refs []string, prefix string) {
+	for _, ref := range refs {
+		target, ok := resolveReference(ref, prefix)
+		if !ok || target == node || slices.Contains(g[target], node) {
+			continue
+		}
+		g[target] = append(g[target], node)
+	}
+}
+
+// dependents returns every resource and output reachable from node, sorted by
+// address.
+func (g referenceGraph) dependents(node string) []string {
+	seen := map[string]bool{node: true}
+	queue := []string{node}
+	var result []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range g[current] {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+			if dependentKind(next) != "" {
+				result = append(result, next)
+			}
+		}
+	}
+
+	slices.Sort(result)
+	return result
+}
+
+func expressionReferences(expressions map[string]*tfjson.Expression) []string {
+	var refs []string
+	for _, expr := range expressions {
+		if expr == nil {
+			continue
+		}
+		refs = append(refs, expr.References...)
+		for _, block := range expr.NestedBlocks {
+			refs = append(refs, expressionReferences(block)...)
+		}
+	}
+	return refs
+}
+
+// resolveReference turns a reference as written in a module ("aws_vpc.main.id",
+// "module.net.vpc_id", "var.cidr") into the graph node it points at.
+func resolveReference(ref, prefix string) (string, bool) {
+	parts := strings.Split(instanceKeyRe.ReplaceAllString(ref, ""), ".")
+
+	switch parts[0] {
+	case "var":
+		if len(parts) < 2 {
+			return "", false
+		}
+		return prefix + "var." + parts[1], true
+	case "module":
+		if len(parts) < 3 {
+			return "", false
+		}
+		return prefix + "module." + parts[1] + ".output." + parts[2], true
+	case "data":
+		if len(parts) < 3 {
+			return "", false
+		}
+		return prefix + strings.Join(parts[:3], "."), true
+	case "local", "each", "count", "path", "terraform", "self":
+		return "", false
+	default:
+		if len(parts) < 2 {
+			return "", false
+		}
+		return prefix + strings.Join(parts[:2], "."), true
+	}
+}
+
+// dependentKind classifies a graph node. Module input variables and module
+// outputs only carry references between modules and are not reported.
+func dependentKind(node string) string {
+	parts := strings.Split(node, ".")
+	inModule := false
+	for len(parts) > 2 && parts[0] == "module" {
+		parts = parts[2:]
+		inModule = true
+	}
+	switch {
+	case parts[0] == "var":
+		return ""
+	case parts[0] == "output" && inModule:
+		return ""
+	case parts[0] == "output":
+		return DependentOutput
+	default:
+		return DependentResource
+	}
+}
+
+// configAddress returns the configuration address of a resource change, which
+// is its address with every module and resource instance key removed.
+func configAddress(change *tfjson.ResourceChange) string {
+	var sb strings.Builder
+	if change.ModuleAddress != "" {
+		sb.WriteString(instanceKeyRe.ReplaceAllString(change.ModuleAddress, ""))
+		sb.WriteString(".")
+	}
+	if change.Mode == tfjson.DataResourceMode {
+		sb.WriteString("data.")
+	}
+	sb.WriteString(change.Type + "." + change.Name)
+	return sb.String()
+}
+
+// analyzeDependents lists the dependents of every changed resource, marking
+// which of them are changed by the same plan.
+func analyzeDependents(planData *PlanData, plan *tfjson.Plan, refs referenceGraph) {
+	changing := make(map[string]bool)
+	for _, change := range plan.ResourceChanges {
+		if change.Change != nil && !change.Change.Actions.NoOp() && !change.Change.Actions.Read() {
+			changing[configAddress(change)] = true
+		}
+	}
+	for name, change := range plan.OutputChanges {
+		if change != nil && !change.Actions.NoOp() {
+			changing["output."+name] = true
+		}
+	}
+
+	for _, resources := range [][]*ResourceData{planData.CreatedResources, planData.UpdatedResources, planData.RecreatedResources, planData.DeletedResources} {
+		for _, resource := range resources {
+			for _, node := range refs.dependents(resource.configAddress) {
+				resource.Dependents = append(resource.Dependents, &Dependent{
+					Address:  node,
+					Kind:     dependentKind(node),
+					Changing: changing[node],
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAnalyzeDependents(t *testing.T) {
+	plan, err := loadAndValidatePlan(filepath.Join("testdata", "plan-dependencies.json"))
+	if err != nil {
+		t.Fatalf("loadAndValidatePlan() error = %v", err)
+	}
+
+	planData, err := processChanges(plan.ResourceChanges, "plan-dependencies.json")
+	if err != nil {
+		t.Fatalf("processChanges() error = %v", err)
+	}
+	analyzeDependents(planData, plan, buildReferenceGraph(plan.Config))
+
+	if len(planData.RecreatedResources) != 1 {
+		t.Fatalf("RecreatedResources len = %d, want 1", len(planData.RecreatedResources))
+	}
+
+	got := planData.RecreatedResources[0].Dependents
+	want := []*Dependent{
+		{Address: "aws_route53_record.db", Kind: DependentResource, Changing: false},
+		{Address: "module.app.aws_instance.app", Kind: DependentResource, Changing: false},
+		{Address: "output.db_endpoint", Kind: DependentOutput, Changing: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Dependents mismatch (-want +got):\n%s", diff)
+	}
+
+	if n := len(planData.RecreatedResources[0].UnchangedDependents()); n != 2 {
+		t.Errorf("UnchangedDependents() len = %d, want 2", n)
+	}
+
+	if len(planData.UpdatedResources) != 1 {
+		t.Fatalf("UpdatedResources len = %d, want 1", len(planData.UpdatedResources))
+	}
+
+	updated := planData.UpdatedResources[0]
+	want = []*Dependent{
+		{Address: "aws_db_instance.main", Kind: DependentResource, Changing: true},
+		{Address: "aws_route53_record.db", Kind: DependentResource, Changing: false},
+		{Address: "module.app.aws_instance.app", Kind: DependentResource, Changing: false},
+		{Address: "output.db_endpoint", Kind: DependentOutput, Changing: true},
+	}
+	if diff := cmp.Diff(want, updated.Dependents); diff != "" {
+		t.Errorf("%s: Dependents mismatch (-want +got):\n%s", updated.Address, diff)
+	}
+
+	if got := updated.UnchangedDependents(); got != nil {
+		t.Errorf("%s: UnchangedDependents() = %v, want none for non-destructive change", updated.Address, got)
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	tests := []struct {
+		name   string
+		ref    string
+		prefix string
+		want   string
+		wantOK bool
+	}{
+		{name: "resource_attribute", ref: "aws_vpc.main.id", want: "aws_vpc.main", wantOK: true},
+		{name: "indexed_resource", ref: "aws_subnet.private[0].id", want: "aws_subnet.private", wantOK: true},
+		{name: "data_source", ref: "data.aws_ami.ubuntu.id", want: "data.aws_ami.ubuntu", wantOK: true},
+		{name: "module_output", ref: "module.net.vpc_id", want: "module.net.output.vpc_id", wantOK: true},
+		{name: "variable_in_module", ref: "var.cidr", prefix: "module.net.", want: "module.net.var.cidr", wantOK: true},
+		{name: "local", ref: "local.tags", wantOK: false},
+		{name: "each", ref: "each.value", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveReference(tt.ref, tt.prefix)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("resolveReference(%q, %q) = %q, %v, want %q, %v", tt.ref, tt.prefix, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Detail levels, from the most to the least detailed. Each level drops more
+// of the comment to fit a size budget.
+const (
+	DetailFull        = "full"
+	DetailFolded      = "folded"
+	DetailDestructive = "destructive"
+	DetailAddresses   = "addresses"
+	DetailCounts      = "counts"
+)
+
+// DetailLevels lists the detail levels in the order they are tried.
+var DetailLevels = []string{DetailFull, DetailFolded, DetailDestructive, DetailAddresses, DetailCounts}
+
+// WithDetail returns a copy of the plan data reduced to the given detail
+// level, leaving the original untouched:
+//   - folded hides the unchanged lines of every diff;
+//   - destructive keeps folded diffs only for recreated and deleted resources;
+//   - addresses drops all diffs, listing addresses only;
+//   - counts drops the resource lists and sections that describe individual
+//     resources, keeping the change counts and summary tables.
+func (m *MultiPlanData) WithDetail(level string) *MultiPlanData {
+	reduced := *m
+	reduced.Display.Detail = level
+	if level == DetailFull {
+		return &reduced
+	}
+
+	reduced.Plans = make([]*PlanWithIdentifier, len(m.Plans))
+	for i, plan := range m.Plans {
+		planCopy := *plan
+		planCopy.Data = plan.Data.withDetail(level)
+		reduced.Plans[i] = &planCopy
+	}
+	return &reduced
+}
+
+func (p *PlanData) withDetail(level string) *PlanData {
+	reduced := *p
+	if level == DetailCounts {
+		reduced.CreatedResources = nil
+		reduced.UpdatedResources = nil
+		reduced.RecreatedResources = nil
+		reduced.DeletedResources = nil
+		reduced.Moves = nil
+		reduced.IndexShifts = nil
+		reduced.Graph = nil
+		return &reduced
+	}
+
+	reduced.CreatedResources = reduceResources(p.CreatedResources, level)
+	reduced.UpdatedResources = reduceResources(p.UpdatedResources, level)
+	reduced.RecreatedResources = reduceResources(p.RecreatedResources, level)
+	reduced.DeletedResources = reduceResources(p.DeletedResources, level)
+	if level == DetailAddresses {
+		reduced.IndexShifts = make([]*IndexShift, len(p.IndexShifts))
+		for i, shift := range p.IndexShifts {
+			shiftCopy := *shift
+			shiftCopy.RemovedDiff = ""
+			reduced.IndexShifts[i] = &shiftCopy
+		}
+	}
+	return &reduced
+}
+
+func reduceResources(resources []*ResourceData, level string) []*ResourceData {
+	result := make([]*ResourceData, len(resources))
+	for i, resource := range resources {
+		reduced := *resource
+		switch {
+		case level == DetailAddresses, level == DetailDestructive && !resource.Destructive():
+			reduced.Diff = ""
+		default:
+			reduced.Diff = foldDiff(resource.Diff)
+		}
+		result[i] = &reduced
+	}
+	return result
+}
+
+// minFoldedLines is the shortest run of unchanged lines worth replacing
+// with a note.
+const minFoldedLines = 3
+
+// foldDiff replaces every run of at least minFoldedLines unchanged lines in a
+// diff with a single line saying how many were hidden.
+func foldDiff(diff string) string {
+	var result, unchanged []string
+	flush := func() {
+		if len(unchanged) >= minFoldedLines {
+			result = append(result, fmt.Sprintf(" … %d unchanged lines", len(unchanged)))
+		} else {
+			result = append(result, unchanged...)
+		}
+		unchanged = nil
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			flush()
+			result = append(result, line)
+			continue
+		}
+		unchanged = append(unchanged, line)
+	}
+	flush()
+	return strings.Join(result, "\n")
+}
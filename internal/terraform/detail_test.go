@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFoldDiff(t *testing.T) {
+	diff := " ami = \"a\"\n-size = 1\n+size = 2\n tags = {\n   a = 1\n   b = 2\n }\n-name = \"x\""
+	want := " ami = \"a\"\n-size = 1\n+size = 2\n … 4 unchanged lines\n-name = \"x\""
+	if got := foldDiff(diff); got != want {
+		t.Errorf("foldDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestWithDetail(t *testing.T) {
+	data := &MultiPlanData{
+		Plans: []*PlanWithIdentifier{{
+			Name: "prod",
+			Data: &PlanData{
+				Summary:          ChangeSummary{Update: 1, Delete: 1},
+				UpdatedResources: []*ResourceData{{Address: "aws_instance.web", Action: "update", Diff: "-a = 1\n+a = 2"}},
+				DeletedResources: []*ResourceData{{Address: "aws_s3_bucket.logs", Action: "delete", Diff: "-bucket = \"logs\""}},
+			},
+		}},
+	}
+
+	diffs := func(m *MultiPlanData) []string {
+		var result []string
+		plan := m.Plans[0].Data
+		for _, resource := range append(plan.UpdatedResources, plan.DeletedResources...) {
+			result = append(result, resource.Address+": "+resource.Diff)
+		}
+		return result
+	}
+
+	tests := []struct {
+		level string
+		want  []string
+	}{
+		{level: DetailFull, want: []string{"aws_instance.web: -a = 1\n+a = 2", "aws_s3_bucket.logs: -bucket = \"logs\""}},
+		{level: DetailDestructive, want: []string{"aws_instance.web: ", "aws_s3_bucket.logs: -bucket = \"logs\""}},
+		{level: DetailAddresses, want: []string{"aws_instance.web: ", "aws_s3_bucket.logs: "}},
+		{level: DetailCounts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			reduced := data.WithDetail(tt.level)
+			if reduced.Display.Detail != tt.level {
+				t.Errorf("Display.Detail = %q, want %q", reduced.Display.Detail, tt.level)
+			}
+			if diff := cmp.Diff(tt.want, diffs(reduced)); diff != "" {
+				t.Errorf("WithDetail() mismatch (-want +got):\n%s", diff)
+			}
+			if reduced.Plans[0].Data.Summary != data.Plans[0].Data.Summary {
+				t.Errorf("WithDetail() changed the summary")
+			}
+		})
+	}
+
+	if got := data.Plans[0].Data.UpdatedResources[0].Diff; got != "-a = 1\n+a = 2" {
+		t.Errorf("WithDetail() modified the original data, diff is now %q", got)
+	}
+}
@@ -0,0 +1,159 @@
+package terraform
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxGraphNodes is the number of changed resources above which the change
+// graph is collapsed to one node per module.
+const maxGraphNodes = 40
+
+const rootModuleLabel = "root module"
+
+// ChangeGraph is the reference graph between the resources changed by a plan.
+type ChangeGraph struct {
+	Nodes     []*GraphNode
+	Edges     []*GraphEdge
+	Collapsed bool
+}
+
+type GraphNode struct {
+	ID     string
+	Label  string
+	Action string
+}
+
+// GraphEdge points from a resource to a resource that references it.
+type GraphEdge struct {
+	From string
+	To   string
+}
+
+var actionSeverity = map[string]int{
+	"create":   1,
+	"update":   2,
+	"recreate": 3,
+	"delete":   4,
+}
+
+// buildChangeGraph connects the changed resources of a plan along the
+// references in its configuration. References that pass through module
+// inputs and outputs are followed; references through unchanged resources
+// are not.
+func buildChangeGraph(planData *PlanData, refs referenceGraph) *ChangeGraph {
+	actions := make(map[string]string)
+	for _, resources := range [][]*ResourceData{
+		planData.CreatedResources,
+		planData.UpdatedResources,
+		planData.RecreatedResources,
+		planData.DeletedResources,
+	} {
+		for _, resource := range resources {
+			actions[resource.configAddress] = mostSevere(actions[resource.configAddress], resource.Action)
+		}
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	var edges [][2]string
+	for from := range actions {
+		for _, to := range refs.changedDependents(from, actions) {
+			edges = append(edges, [2]string{from, to})
+		}
+	}
+
+	collapsed := len(actions) > maxGraphNodes
+	if collapsed {
+		moduleActions := make(map[string]string)
+		for address, action := range actions {
+			module := moduleOf(address)
+			moduleActions[module] = mostSevere(moduleActions[module], action)
+		}
+		var moduleEdges [][2]string
+		for _, edge := range edges {
+			from, to := moduleOf(edge[0]), moduleOf(edge[1])
+			if from != to && !slices.Contains(moduleEdges, [2]string{from, to}) {
+				moduleEdges = append(moduleEdges, [2]string{from, to})
+			}
+		}
+		actions, edges = moduleActions, moduleEdges
+	}
+
+	labels := make([]string, 0, len(actions))
+	for label := range actions {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+
+	graph := &ChangeGraph{Collapsed: collapsed}
+	ids := make(map[string]string, len(labels))
+	for i, label := range labels {
+		ids[label] = fmt.Sprintf("n%d", i)
+		graph.Nodes = append(graph.Nodes, &GraphNode{
+			ID:     ids[label],
+			Label:  label,
+			Action: actions[label],
+		})
+	}
+
+	slices.SortFunc(edges, func(a, b [2]string) int {
+		return strings.Compare(a[0]+"\x00"+a[1], b[0]+"\x00"+b[1])
+	})
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, &GraphEdge{From: ids[edge[0]], To: ids[edge[1]]})
+	}
+
+	return graph
+}
+
+// changedDependents returns the changed resources that reference node without
+// another resource in between.
+func (g referenceGraph) changedDependents(node string, changed map[string]string) []string {
+	seen := map[string]bool{node: true}
+	queue := []string{node}
+	var result []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range g[current] {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			switch dependentKind(next) {
+			case "":
+				queue = append(queue, next)
+			case DependentResource:
+				if _, ok := changed[next]; ok {
+					result = append(result, next)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func mostSevere(a, b string) string {
+	if actionSeverity[b] > actionSeverity[a] {
+		return b
+	}
+	return a
+}
+
+// moduleOf returns the module path of a configuration address.
+func moduleOf(address string) string {
+	parts := strings.Split(address, ".")
+	i := 0
+	for i+2 < len(parts) && parts[i] == "module" {
+		i += 2
+	}
+	if i == 0 {
+		return rootModuleLabel
+	}
+	return strings.Join(parts[:i], ".")
+}
@@ -0,0 +1,80 @@
+package terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildChangeGraph(t *testing.T) {
+	refs := referenceGraph{
+		"aws_vpc.main":             {"module.net.var.vpc_id"},
+		"module.net.var.vpc_id":    {"module.net.aws_subnet.a"},
+		"module.net.aws_subnet.a":  {"module.net.output.subnet"},
+		"module.net.output.subnet": {"aws_instance.web"},
+		"aws_instance.web":         {"aws_eip.web"},
+	}
+	planData := &PlanData{
+		CreatedResources: []*ResourceData{
+			{Address: "module.net.aws_subnet.a[0]", Action: "create", configAddress: "module.net.aws_subnet.a"},
+			{Address: "aws_eip.web", Action: "create", configAddress: "aws_eip.web"},
+		},
+		RecreatedResources: []*ResourceData{
+			{Address: "aws_vpc.main", Action: "recreate", configAddress: "aws_vpc.main"},
+		},
+		DeletedResources: []*ResourceData{
+			{Address: "module.net.aws_subnet.a[1]", Action: "delete", configAddress: "module.net.aws_subnet.a"},
+		},
+	}
+
+	got := buildChangeGraph(planData, refs)
+	want := &ChangeGraph{
+		Nodes: []*GraphNode{
+			{ID: "n0", Label: "aws_eip.web", Action: "create"},
+			{ID: "n1", Label: "aws_vpc.main", Action: "recreate"},
+			{ID: "n2", Label: "module.net.aws_subnet.a", Action: "delete"},
+		},
+		Edges: []*GraphEdge{
+			{From: "n1", To: "n2"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("buildChangeGraph() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildChangeGraphCollapsesLargeGraphs(t *testing.T) {
+	refs := referenceGraph{"aws_vpc.main": {"module.net.var.vpc_id"}}
+	planData := &PlanData{
+		UpdatedResources: []*ResourceData{
+			{Address: "aws_vpc.main", Action: "update", configAddress: "aws_vpc.main"},
+		},
+	}
+	for i := 0; i < maxGraphNodes; i++ {
+		address := fmt.Sprintf("module.net.aws_subnet.s%d", i)
+		planData.CreatedResources = append(planData.CreatedResources, &ResourceData{Address: address, Action: "create", configAddress: address})
+		refs["module.net.var.vpc_id"] = append(refs["module.net.var.vpc_id"], address)
+	}
+
+	got := buildChangeGraph(planData, refs)
+	want := &ChangeGraph{
+		Nodes: []*GraphNode{
+			{ID: "n0", Label: "module.net", Action: "create"},
+			{ID: "n1", Label: rootModuleLabel, Action: "update"},
+		},
+		Edges: []*GraphEdge{
+			{From: "n1", To: "n0"},
+		},
+		Collapsed: true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("buildChangeGraph() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildChangeGraphNoChanges(t *testing.T) {
+	if got := buildChangeGraph(&PlanData{}, referenceGraph{}); got != nil {
+		t.Errorf("buildChangeGraph() = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,86 @@
+package terraform
+
+import (
+	"regexp"
+	"strings"
+)
+
+var instanceSuffixRe = regexp.MustCompile(`\[([^\[\]]*)\]$`)
+
+// groupIdenticalDiffs merges instances of the same count or for_each resource
+// whose diffs change the same lines in the same way into a single entry. The
+// merged entry keeps the first instance's diff and lists every instance in
+// Instances. Instances that are part of an index shift are never merged.
+func groupIdenticalDiffs(resources []*ResourceData) []*ResourceData {
+	type groupKey struct {
+		base    string
+		changes string
+	}
+
+	groups := make(map[groupKey][]*ResourceData)
+	for _, resource := range resources {
+		base, _, ok := splitInstanceKey(resource.Address)
+		if !ok || resource.IndexShifted {
+			continue
+		}
+		key := groupKey{base: base, changes: changedLines(resource.Diff)}
+		groups[key] = append(groups[key], resource)
+	}
+
+	result := make([]*ResourceData, 0, len(resources))
+	emitted := make(map[groupKey]bool)
+	for _, resource := range resources {
+		base, instanceKey, ok := splitInstanceKey(resource.Address)
+		if !ok || resource.IndexShifted {
+			result = append(result, resource)
+			continue
+		}
+
+		key := groupKey{base: base, changes: changedLines(resource.Diff)}
+		members := groups[key]
+		if len(members) < 2 {
+			result = append(result, resource)
+			continue
+		}
+		if emitted[key] {
+			continue
+		}
+		emitted[key] = true
+
+		wildcard := "[*]"
+		if strings.HasPrefix(instanceKey, `"`) {
+			wildcard = `["*"]`
+		}
+
+		group := *members[0]
+		group.Address = base + wildcard
+		group.Instances = members
+		result = append(result, &group)
+	}
+
+	return result
+}
+
+// splitInstanceKey splits a resource address into its address without the
+// final instance key and that key, e.g. `aws_subnet.private["a"]` into
+// `aws_subnet.private` and `"a"`.
+func splitInstanceKey(address string) (string, string, bool) {
+	loc := instanceSuffixRe.FindStringSubmatchIndex(address)
+	if loc == nil {
+		return "", "", false
+	}
+	return address[:loc[0]], address[loc[2]:loc[3]], true
+}
+
+// changedLines returns the added and removed lines of a diff, which identify
+// a change independently of the unchanged attributes around it.
+func changedLines(diff string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
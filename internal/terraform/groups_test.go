@@ -0,0 +1,81 @@
+package terraform
+
+import (
+	"testing"
+)
+
+func TestGroupIdenticalDiffs(t *testing.T) {
+	tagDiff := func(cidr string) string {
+		return " cidr_block = \"" + cidr + "\"\n tags = {\n-  Team = \"net\"\n+  Team = \"platform\"\n }"
+	}
+
+	resources := []*ResourceData{
+		{Address: `aws_subnet.private["a"]`, Diff: tagDiff("10.0.1.0/24")},
+		{Address: `aws_subnet.private["b"]`, Diff: tagDiff("10.0.2.0/24")},
+		{Address: `aws_subnet.private["c"]`, Diff: " cidr_block = \"10.0.3.0/24\"\n-map_public_ip_on_launch = false\n+map_public_ip_on_launch = true"},
+		{Address: "aws_instance.web[0]", Diff: "-ami = \"ami-1\"\n+ami = \"ami-2\""},
+		{Address: "aws_instance.web[1]", Diff: "-ami = \"ami-1\"\n+ami = \"ami-2\""},
+		{Address: "aws_instance.web[2]", Diff: "-ami = \"ami-1\"\n+ami = \"ami-2\"", IndexShifted: true},
+		{Address: "aws_vpc.main", Diff: "-ami = \"ami-1\"\n+ami = \"ami-2\""},
+	}
+
+	got := groupIdenticalDiffs(resources)
+
+	wantAddresses := []string{
+		`aws_subnet.private["*"]`,
+		`aws_subnet.private["c"]`,
+		"aws_instance.web[*]",
+		"aws_instance.web[2]",
+		"aws_vpc.main",
+	}
+	if len(got) != len(wantAddresses) {
+		t.Fatalf("len = %d, want %d", len(got), len(wantAddresses))
+	}
+	for i, want := range wantAddresses {
+		if got[i].Address != want {
+			t.Errorf("got[%d].Address = %q, want %q", i, got[i].Address, want)
+		}
+	}
+
+	wantInstances := map[string][]string{
+		`aws_subnet.private["*"]`: {`aws_subnet.private["a"]`, `aws_subnet.private["b"]`},
+		"aws_instance.web[*]":     {"aws_instance.web[0]", "aws_instance.web[1]"},
+	}
+	for _, resource := range got {
+		want := wantInstances[resource.Address]
+		if len(resource.Instances) != len(want) {
+			t.Errorf("%s: Instances len = %d, want %d", resource.Address, len(resource.Instances), len(want))
+			continue
+		}
+		for i, instance := range resource.Instances {
+			if instance.Address != want[i] {
+				t.Errorf("%s: Instances[%d] = %q, want %q", resource.Address, i, instance.Address, want[i])
+			}
+		}
+		if len(resource.Members()) != max(len(want), 1) {
+			t.Errorf("%s: Members() len = %d", resource.Address, len(resource.Members()))
+		}
+	}
+}
+
+func TestSplitInstanceKey(t *testing.T) {
+	tests := []struct {
+		address  string
+		wantBase string
+		wantKey  string
+		wantOK   bool
+	}{
+		{address: `aws_subnet.private["a"]`, wantBase: "aws_subnet.private", wantKey: `"a"`, wantOK: true},
+		{address: `module.net["eu"].aws_subnet.private[3]`, wantBase: `module.net["eu"].aws_subnet.private`, wantKey: "3", wantOK: true},
+		{address: `module.net["eu"].aws_vpc.main`, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			base, key, ok := splitInstanceKey(tt.address)
+			if base != tt.wantBase || key != tt.wantKey || ok != tt.wantOK {
+				t.Errorf("splitInstanceKey(%q) = %q, %q, %v, want %q, %q, %v", tt.address, base, key, ok, tt.wantBase, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
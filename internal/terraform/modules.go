@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	GroupByAction = "action"
+	GroupByModule = "module"
+)
+
+var moduleSegmentRe = regexp.MustCompile(`module\.[^.\[]+(\[[^\]]*\])?`)
+
+// ModuleGroup holds the changes made inside one module instance. Summary
+// counts the module's own changes, listed in the group, while Total also
+// counts those of all modules nested in it.
+type ModuleGroup struct {
+	Address            string
+	Summary            ChangeSummary
+	Total              ChangeSummary
+	CreatedResources   []*ResourceData
+	UpdatedResources   []*ResourceData
+	RecreatedResources []*ResourceData
+	DeletedResources   []*ResourceData
+	Children           []*ModuleGroup
+}
+
+// IsRoot reports whether the group holds the root module's resources.
+func (m *ModuleGroup) IsRoot() bool {
+	return m.Address == ""
+}
+
+// ModuleTree nests the plan's changes by module address. Only modules that
+// contain changes, directly or through nested modules, are included. The root
+// module's own resources come first, followed by modules sorted by address.
+func (p *PlanData) ModuleTree() []*ModuleGroup {
+	root := &ModuleGroup{}
+	index := make(map[string]*ModuleGroup)
+
+	for _, resources := range [][]*ResourceData{p.CreatedResources, p.UpdatedResources, p.RecreatedResources, p.DeletedResources} {
+		for _, resource := range resources {
+			count := len(resource.Members())
+			group := root
+			address := ""
+			for _, segment := range moduleSegmentRe.FindAllString(resource.ModuleAddress, -1) {
+				if address != "" {
+					address += "."
+				}
+				address += segment
+				child, ok := index[address]
+				if !ok {
+					child = &ModuleGroup{Address: address}
+					index[address] = child
+					group.Children = append(group.Children, child)
+				}
+				child.Total.add(resource.Action, count)
+				group = child
+			}
+			if group == root {
+				root.Total.add(resource.Action, count)
+			}
+			group.Summary.add(resource.Action, count)
+			group.addResource(resource)
+		}
+	}
+
+	sortModuleGroups(root.Children)
+
+	var result []*ModuleGroup
+	if root.Total.Total() > 0 {
+		result = append(result, root)
+	}
+	result = append(result, root.Children...)
+	root.Children = nil
+	return result
+}
+
+func (m *ModuleGroup) addResource(resource *ResourceData) {
+	switch resource.Action {
+	case "create":
+		m.CreatedResources = append(m.CreatedResources, resource)
+	case "update":
+		m.UpdatedResources = append(m.UpdatedResources, resource)
+	case "recreate":
+		m.RecreatedResources = append(m.RecreatedResources, resource)
+	case "delete":
+		m.DeletedResources = append(m.DeletedResources, resource)
+	}
+}
+
+func sortModuleGroups(groups []*ModuleGroup) {
+	slices.SortFunc(groups, func(a, b *ModuleGroup) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	for _, group := range groups {
+		sortModuleGroups(group.Children)
+	}
+}
@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"testing"
+)
+
+func TestModuleTree(t *testing.T) {
+	planData := &PlanData{
+		CreatedResources: []*ResourceData{
+			{Address: "module.net.aws_subnet.a", ModuleAddress: "module.net", Action: "create"},
+			{
+				Address:       `module.net.module.sg["web"].aws_security_group_rule.r[*]`,
+				ModuleAddress: `module.net.module.sg["web"]`,
+				Action:        "create",
+				Instances:     []*ResourceData{{}, {}, {}},
+			},
+		},
+		UpdatedResources: []*ResourceData{
+			{Address: "aws_vpc.main", Action: "update"},
+		},
+		DeletedResources: []*ResourceData{
+			{Address: `module.net.module.sg["web"].aws_security_group.this`, ModuleAddress: `module.net.module.sg["web"]`, Action: "delete"},
+			{Address: "module.app.aws_instance.web", ModuleAddress: "module.app", Action: "delete"},
+		},
+	}
+
+	tree := planData.ModuleTree()
+
+	wantTop := []struct {
+		address string
+		summary ChangeSummary
+	}{
+		{address: "", summary: ChangeSummary{Update: 1}},
+		{address: "module.app", summary: ChangeSummary{Delete: 1}},
+		{address: "module.net", summary: ChangeSummary{Create: 4, Delete: 1}},
+	}
+	if len(tree) != len(wantTop) {
+		t.Fatalf("len = %d, want %d", len(tree), len(wantTop))
+	}
+	for i, want := range wantTop {
+		if tree[i].Address != want.address || tree[i].Total != want.summary {
+			t.Errorf("tree[%d] = %q %+v, want %q %+v", i, tree[i].Address, tree[i].Total, want.address, want.summary)
+		}
+	}
+
+	if !tree[0].IsRoot() || len(tree[0].Children) != 0 {
+		t.Errorf("expected root group without children first")
+	}
+
+	net := tree[2]
+	if len(net.CreatedResources) != 1 || len(net.Children) != 1 {
+		t.Fatalf("module.net: created = %d, children = %d, want 1 and 1", len(net.CreatedResources), len(net.Children))
+	}
+	sg := net.Children[0]
+	if sg.Address != `module.net.module.sg["web"]` || sg.Total != (ChangeSummary{Create: 3, Delete: 1}) {
+		t.Errorf("nested module = %q %+v", sg.Address, sg.Total)
+	}
+}
+
+func TestModuleTreeNestedSummaries(t *testing.T) {
+	created := []*ResourceData{
+		{Address: "module.a.aws_s3_bucket.logs", ModuleAddress: "module.a", Action: "create"},
+		{
+			Address:       "module.a.module.b.aws_subnet.s[*]",
+			ModuleAddress: "module.a.module.b",
+			Action:        "create",
+			Instances:     []*ResourceData{{}, {}, {}, {}, {}},
+		},
+	}
+	tree := (&PlanData{CreatedResources: created}).ModuleTree()
+	if len(tree) != 1 || len(tree[0].Children) != 1 {
+		t.Fatalf("expected module.a with one nested module, got %d groups", len(tree))
+	}
+
+	tests := []struct {
+		name        string
+		group       *ModuleGroup
+		wantSummary ChangeSummary
+		wantTotal   ChangeSummary
+	}{
+		{name: "parent", group: tree[0], wantSummary: ChangeSummary{Create: 1}, wantTotal: ChangeSummary{Create: 6}},
+		{name: "nested", group: tree[0].Children[0], wantSummary: ChangeSummary{Create: 5}, wantTotal: ChangeSummary{Create: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.group.Summary != tt.wantSummary {
+				t.Errorf("Summary = %+v, want %+v", tt.group.Summary, tt.wantSummary)
+			}
+			if tt.group.Total != tt.wantTotal {
+				t.Errorf("Total = %+v, want %+v", tt.group.Total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestModuleTreeWithoutChanges(t *testing.T) {
+	if tree := (&PlanData{}).ModuleTree(); len(tree) != 0 {
+		t.Errorf("ModuleTree() len = %d, want 0", len(tree))
+	}
+}
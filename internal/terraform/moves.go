@@ -0,0 +1,127 @@
+package terraform
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// moveSimilarityThreshold is the share of a created resource's known
+// attributes that must equal those of a deleted resource for the pair to be
+// suggested as a move.
+const moveSimilarityThreshold = 0.9
+
+// MoveSuggestion is a deleted and a created resource that are likely the same
+// object under a new address, and could be reconciled with a moved block.
+type MoveSuggestion struct {
+	From       string
+	To         string
+	Similarity float64
+}
+
+// SimilarityPercent returns the similarity as a whole percentage.
+func (m *MoveSuggestion) SimilarityPercent() int {
+	return int(m.Similarity * 100)
+}
+
+// suggestMoves pairs deleted and created resources of the same type whose
+// known attributes match. Attributes that are unknown until apply, such as
+// generated IDs, are ignored. Each resource appears in at most one pair, best
+// matches first.
+func suggestMoves(deleted, created []*tfjson.ResourceChange) []*MoveSuggestion {
+	var candidates []*MoveSuggestion
+
+	for _, to := range created {
+		after, _ := to.Change.After.(map[string]interface{})
+		keys := knownAttributes(after, to.Change.AfterUnknown)
+		if len(keys) == 0 {
+			continue
+		}
+
+		for _, from := range deleted {
+			if from.Type != to.Type || from.Mode != to.Mode {
+				continue
+			}
+			before, _ := from.Change.Before.(map[string]interface{})
+
+			matching := 0
+			for _, key := range keys {
+				if reflect.DeepEqual(before[key], after[key]) {
+					matching++
+				}
+			}
+
+			similarity := float64(matching) / float64(len(keys))
+			if similarity >= moveSimilarityThreshold {
+				candidates = append(candidates, &MoveSuggestion{
+					From:       from.Address,
+					To:         to.Address,
+					Similarity: similarity,
+				})
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b *MoveSuggestion) int {
+		return cmp.Or(
+			cmp.Compare(b.Similarity, a.Similarity),
+			cmp.Compare(a.From, b.From),
+			cmp.Compare(a.To, b.To),
+		)
+	})
+
+	used := make(map[string]bool)
+	var suggestions []*MoveSuggestion
+	for _, candidate := range candidates {
+		if used[candidate.From] || used[candidate.To] {
+			continue
+		}
+		used[candidate.From] = true
+		used[candidate.To] = true
+		suggestions = append(suggestions, candidate)
+	}
+
+	slices.SortFunc(suggestions, func(a, b *MoveSuggestion) int {
+		return cmp.Compare(a.From, b.From)
+	})
+
+	return suggestions
+}
+
+// knownAttributes returns the non-null attributes of a planned value that are
+// known at plan time.
+func knownAttributes(after map[string]interface{}, afterUnknown interface{}) []string {
+	unknown, _ := afterUnknown.(map[string]interface{})
+
+	var keys []string
+	for key, value := range after {
+		if value == nil || containsUnknown(unknown[key]) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func containsUnknown(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case map[string]interface{}:
+		for _, nested := range val {
+			if containsUnknown(nested) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, nested := range val {
+			if containsUnknown(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func deleteChange(address, resourceType string, before map[string]interface{}) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: address,
+		Mode:    tfjson.ManagedResourceMode,
+		Type:    resourceType,
+		Change: &tfjson.Change{
+			Actions: []tfjson.Action{tfjson.ActionDelete},
+			Before:  before,
+		},
+	}
+}
+
+func createChange(address, resourceType string, after map[string]interface{}, afterUnknown map[string]interface{}) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: address,
+		Mode:    tfjson.ManagedResourceMode,
+		Type:    resourceType,
+		Change: &tfjson.Change{
+			Actions:      []tfjson.Action{tfjson.ActionCreate},
+			After:        after,
+			AfterUnknown: afterUnknown,
+		},
+	}
+}
+
+func TestSuggestMoves(t *testing.T) {
+	subnetA := map[string]interface{}{"cidr_block": "10.0.1.0/24", "vpc_id": "vpc-1", "tags": map[string]interface{}{"Name": "a"}}
+	subnetB := map[string]interface{}{"cidr_block": "10.0.2.0/24", "vpc_id": "vpc-1", "tags": map[string]interface{}{"Name": "b"}}
+
+	tests := []struct {
+		name    string
+		deleted []*tfjson.ResourceChange
+		created []*tfjson.ResourceChange
+		want    []*MoveSuggestion
+	}{
+		{
+			name: "count_to_for_each",
+			deleted: []*tfjson.ResourceChange{
+				deleteChange("aws_subnet.private[0]", "aws_subnet", withID(subnetA, "subnet-a")),
+				deleteChange("aws_subnet.private[1]", "aws_subnet", withID(subnetB, "subnet-b")),
+			},
+			created: []*tfjson.ResourceChange{
+				createChange(`aws_subnet.private["b"]`, "aws_subnet", subnetB, map[string]interface{}{"id": true}),
+				createChange(`aws_subnet.private["a"]`, "aws_subnet", subnetA, map[string]interface{}{"id": true}),
+			},
+			want: []*MoveSuggestion{
+				{From: "aws_subnet.private[0]", To: `aws_subnet.private["a"]`, Similarity: 1},
+				{From: "aws_subnet.private[1]", To: `aws_subnet.private["b"]`, Similarity: 1},
+			},
+		},
+		{
+			name: "rename_with_computed_attributes",
+			deleted: []*tfjson.ResourceChange{
+				deleteChange("aws_s3_bucket.old", "aws_s3_bucket", map[string]interface{}{"bucket": "logs", "arn": "arn:aws:s3:::logs"}),
+			},
+			created: []*tfjson.ResourceChange{
+				createChange("aws_s3_bucket.logs", "aws_s3_bucket", map[string]interface{}{"bucket": "logs", "arn": nil}, map[string]interface{}{"arn": true}),
+			},
+			want: []*MoveSuggestion{
+				{From: "aws_s3_bucket.old", To: "aws_s3_bucket.logs", Similarity: 1},
+			},
+		},
+		{
+			name: "different_types_not_paired",
+			deleted: []*tfjson.ResourceChange{
+				deleteChange("aws_s3_bucket.old", "aws_s3_bucket", map[string]interface{}{"name": "logs"}),
+			},
+			created: []*tfjson.ResourceChange{
+				createChange("aws_sqs_queue.logs", "aws_sqs_queue", map[string]interface{}{"name": "logs"}, nil),
+			},
+			want: nil,
+		},
+		{
+			name: "different_attributes_not_paired",
+			deleted: []*tfjson.ResourceChange{
+				deleteChange("aws_subnet.private[0]", "aws_subnet", subnetA),
+			},
+			created: []*tfjson.ResourceChange{
+				createChange(`aws_subnet.private["b"]`, "aws_subnet", subnetB, nil),
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestMoves(tt.deleted, tt.created)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("suggestMoves() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func withID(attrs map[string]interface{}, id string) map[string]interface{} {
+	result := map[string]interface{}{"id": id}
+	for k, v := range attrs {
+		result[k] = v
+	}
+	return result
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -12,25 +13,222 @@ import (
 )
 
 type ResourceData struct {
-	Address string
-	Diff    string
+	Address       string
+	ModuleAddress string
+	Type          string
+	ProviderName  string
+	Action        string
+	Diff          string
+	Cost          *CostDelta
+	Dependents    []*Dependent
+	IndexShifted  bool
+	RiskyChanges  []*RiskyChange
+	ReplacePaths  []string
+	Source        *SourceLocation
+	Instances     []*ResourceData
+
+	configAddress string
+	before        map[string]interface{}
+	after         map[string]interface{}
+}
+
+// Attributes returns the raw attribute values of a resource before and after
+// the change. They include sensitive values, so they are kept out of the
+// template data, which only carries the redacted Diff.
+func Attributes(resource *ResourceData) (before, after map[string]interface{}) {
+	return resource.before, resource.after
+}
+
+// SetAttributes records the raw attribute values of a resource, as reading a
+// plan file does.
+func SetAttributes(resource *ResourceData, before, after map[string]interface{}) {
+	resource.before, resource.after = before, after
+}
+
+// Members returns the instances merged into this entry, or the entry itself
+// when it describes a single resource.
+func (r *ResourceData) Members() []*ResourceData {
+	if len(r.Instances) > 0 {
+		return r.Instances
+	}
+	return []*ResourceData{r}
+}
+
+// ConfigAddress returns the address of the resource block in the
+// configuration, without instance keys.
+func (r *ResourceData) ConfigAddress() string {
+	return r.configAddress
+}
+
+// SetConfigAddress records the address of the block declaring a resource, as
+// reading a plan file does.
+func SetConfigAddress(resource *ResourceData, address string) {
+	resource.configAddress = address
+}
+
+// LinesAdded returns the number of added lines in the diff.
+func (r *ResourceData) LinesAdded() int {
+	return countDiffLines(r.Diff, "+")
+}
+
+// LinesRemoved returns the number of removed lines in the diff.
+func (r *ResourceData) LinesRemoved() int {
+	return countDiffLines(r.Diff, "-")
+}
+
+func countDiffLines(diff, prefix string) int {
+	count := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// Destructive reports whether the resource is recreated or deleted.
+func (r *ResourceData) Destructive() bool {
+	return r.Action == "recreate" || r.Action == "delete"
+}
+
+// UnchangedDependents returns the dependents that the plan leaves untouched
+// when this resource is recreated or deleted, which are the ones most likely
+// to break when it goes away.
+func (r *ResourceData) UnchangedDependents() []*Dependent {
+	if !r.Destructive() {
+		return nil
+	}
+	var result []*Dependent
+	for _, dependent := range r.Dependents {
+		if !dependent.Changing {
+			result = append(result, dependent)
+		}
+	}
+	return result
+}
+
+// SourceLocation is where a resource block is declared, relative to the
+// repository root, with a link to it when one could be built. Line is the
+// first line of the block and EndLine its last.
+type SourceLocation struct {
+	File    string
+	Line    int
+	EndLine int
+	URL     string
+}
+
+type CostDelta struct {
+	Currency string
+	Before   float64
+	After    float64
+}
+
+func (c *CostDelta) Delta() float64 {
+	return c.After - c.Before
 }
 
 type PlanData struct {
 	HasChanges         bool
+	Summary            ChangeSummary
+	ByType             []*SummaryRow
+	ByProvider         []*SummaryRow
 	CreatedResources   []*ResourceData
 	UpdatedResources   []*ResourceData
 	RecreatedResources []*ResourceData
 	DeletedResources   []*ResourceData
+	Cost               *CostDelta
+	Graph              *ChangeGraph
+	Moves              []*MoveSuggestion
+	IndexShifts        []*IndexShift
+	Checks             []*CheckResult
+	UnchangedResources []string
+}
+
+// ChangeSummary counts the resources changed by each kind of action.
+type ChangeSummary struct {
+	Create   int
+	Update   int
+	Recreate int
+	Delete   int
+}
+
+func (s ChangeSummary) Total() int {
+	return s.Create + s.Update + s.Recreate + s.Delete
+}
+
+func (s ChangeSummary) plus(other ChangeSummary) ChangeSummary {
+	return ChangeSummary{
+		Create:   s.Create + other.Create,
+		Update:   s.Update + other.Update,
+		Recreate: s.Recreate + other.Recreate,
+		Delete:   s.Delete + other.Delete,
+	}
+}
+
+func (s *ChangeSummary) add(action string, count int) {
+	switch action {
+	case "create":
+		s.Create += count
+	case "update":
+		s.Update += count
+	case "recreate":
+		s.Recreate += count
+	case "delete":
+		s.Delete += count
+	}
+}
+
+// HasDestructiveChanges reports whether the plan recreates or destroys any
+// resource.
+func (p *PlanData) HasDestructiveChanges() bool {
+	return p.Summary.Recreate > 0 || p.Summary.Delete > 0
+}
+
+// CostedResources returns the resources that carry a cost estimate, in the
+// order they are rendered.
+func (p *PlanData) CostedResources() []*ResourceData {
+	var result []*ResourceData
+	for _, resources := range [][]*ResourceData{p.CreatedResources, p.UpdatedResources, p.RecreatedResources, p.DeletedResources} {
+		for _, resource := range resources {
+			if resource.Cost != nil {
+				result = append(result, resource)
+			}
+		}
+	}
+	return result
 }
 
 type MultiPlanData struct {
 	HasChanges bool
 	Plans      []*PlanWithIdentifier
+	Summary    ChangeSummary
+	ByType     []*SummaryRow
+	ByProvider []*SummaryRow
+	Cost       *CostDelta
+	Display    Display
+}
+
+// HasDestructiveChanges reports whether any of the plans recreates or
+// destroys a resource.
+func (m *MultiPlanData) HasDestructiveChanges() bool {
+	return m.Summary.Recreate > 0 || m.Summary.Delete > 0
+}
+
+// Display holds the optional sections and layout the comment template renders.
+type Display struct {
+	Graph      bool
+	GroupBy    string
+	ByProvider bool
+	// Detail is the detail level the plans were reduced to, and Budget the
+	// size budget in characters that made it necessary.
+	Detail    string
+	Budget    int
+	ReportURL string
 }
 
 type PlanWithIdentifier struct {
 	Name string
+	Path string
 	Data *PlanData
 }
 
@@ -53,14 +251,30 @@ func ProcessMultiplePlans(planFiles []string) (*MultiPlanData, error) {
 		if err != nil {
 			return nil, err
 		}
+		refs := buildReferenceGraph(plan.Config)
+		analyzeDependents(planData, plan, refs)
+		planData.Graph = buildChangeGraph(planData, refs)
+		planData.Checks = collectChecks(plan.Checks)
 
 		multiPlanData.Plans[i] = &PlanWithIdentifier{
 			Name: extractPlanName(planFile),
+			Path: planFile,
 			Data: planData,
 		}
 
 		multiPlanData.HasChanges = multiPlanData.HasChanges || planData.HasChanges
+		multiPlanData.Summary = multiPlanData.Summary.plus(planData.Summary)
+	}
+	uniquePlanNames(multiPlanData.Plans)
+
+	byType := make([][]*SummaryRow, len(multiPlanData.Plans))
+	byProvider := make([][]*SummaryRow, len(multiPlanData.Plans))
+	for i, plan := range multiPlanData.Plans {
+		byType[i] = plan.Data.ByType
+		byProvider[i] = plan.Data.ByProvider
 	}
+	multiPlanData.ByType = mergeRows(byType...)
+	multiPlanData.ByProvider = mergeRows(byProvider...)
 
 	return multiPlanData, nil
 }
@@ -71,9 +285,18 @@ func processChanges(resourceChanges []*tfjson.ResourceChange, planFile string) (
 	}
 
 	var created, updated, recreated, deleted []*tfjson.ResourceChange
+	var unchanged []string
 
 	for _, change := range resourceChanges {
-		if len(change.Change.Actions) == 0 {
+		if change.Change.Actions.NoOp() {
+			if change.Mode == tfjson.ManagedResourceMode {
+				unchanged = append(unchanged, change.Address)
+			}
+			continue
+		}
+		// Data source reads change nothing and determineChangeType has no
+		// kind for them, so they are left out rather than failing the plan.
+		if len(change.Change.Actions) == 0 || change.Change.Actions.Read() {
 			continue
 		}
 
@@ -98,14 +321,54 @@ func processChanges(resourceChanges []*tfjson.ResourceChange, planFile string) (
 	sortByAddress(updated)
 	sortByAddress(recreated)
 	sortByAddress(deleted)
+	slices.Sort(unchanged)
 
-	return &PlanData{
+	planData := &PlanData{
 		CreatedResources:   buildResourceData(created),
 		UpdatedResources:   buildResourceData(updated),
 		RecreatedResources: buildResourceData(recreated),
 		DeletedResources:   buildResourceData(deleted),
+		Moves:              suggestMoves(deleted, created),
+		IndexShifts:        detectIndexShifts(updated, recreated, deleted),
 		HasChanges:         len(created) > 0 || len(updated) > 0 || len(recreated) > 0 || len(deleted) > 0,
-	}, nil
+		UnchangedResources: unchanged,
+	}
+	planData.Summary = ChangeSummary{
+		Create:   len(planData.CreatedResources),
+		Update:   len(planData.UpdatedResources),
+		Recreate: len(planData.RecreatedResources),
+		Delete:   len(planData.DeletedResources),
+	}
+	markIndexShifted(planData)
+
+	planData.ByType = summarizeBy(planData, resourceType)
+	planData.ByProvider = summarizeBy(planData, resourceProvider)
+
+	planData.CreatedResources = groupIdenticalDiffs(planData.CreatedResources)
+	planData.UpdatedResources = groupIdenticalDiffs(planData.UpdatedResources)
+	planData.RecreatedResources = groupIdenticalDiffs(planData.RecreatedResources)
+	planData.DeletedResources = groupIdenticalDiffs(planData.DeletedResources)
+
+	return planData, nil
+}
+
+func markIndexShifted(planData *PlanData) {
+	shifted := make(map[string]bool)
+	for _, shift := range planData.IndexShifts {
+		for _, address := range shift.Shifted {
+			shifted[address] = true
+		}
+		shifted[shift.Deleted] = true
+	}
+	if len(shifted) == 0 {
+		return
+	}
+
+	for _, resources := range [][]*ResourceData{planData.UpdatedResources, planData.RecreatedResources, planData.DeletedResources} {
+		for _, resource := range resources {
+			resource.IndexShifted = shifted[resource.Address]
+		}
+	}
 }
 
 func determineChangeType(actions []tfjson.Action) (string, error) {
@@ -151,10 +414,20 @@ func buildResourceData(resources []*tfjson.ResourceChange) []*ResourceData {
 		if !hasChanges {
 			continue
 		}
+		action, _ := determineChangeType(resource.Change.Actions)
 
 		result = append(result, &ResourceData{
-			Address: resource.Address,
-			Diff:    diff,
+			Address:       resource.Address,
+			ModuleAddress: resource.ModuleAddress,
+			Type:          resource.Type,
+			ProviderName:  resource.ProviderName,
+			Action:        action,
+			Diff:          diff,
+			RiskyChanges:  detectRiskyChanges(beforeMap, afterMap),
+			ReplacePaths:  replacePaths(resource.Change.ReplacePaths),
+			configAddress: configAddress(resource),
+			before:        beforeMap,
+			after:         afterMap,
 		})
 	}
 
@@ -185,3 +458,38 @@ func extractPlanName(filePath string) string {
 	}
 	return strings.TrimSuffix(path.Base(filePath), ".json")
 }
+
+// uniquePlanNames renames plans whose file names collide after as many of
+// their trailing path elements as it takes to tell them apart, so that
+// headings, anchors and per-plan files stay distinct.
+func uniquePlanNames(plans []*PlanWithIdentifier) {
+	for depth := 2; ; depth++ {
+		counts := make(map[string]int, len(plans))
+		for _, plan := range plans {
+			counts[plan.Name]++
+		}
+		renamed := false
+		for _, plan := range plans {
+			if counts[plan.Name] < 2 {
+				continue
+			}
+			if name := trailingPlanPath(plan.Path, depth); name != plan.Name {
+				plan.Name = name
+				renamed = true
+			}
+		}
+		if !renamed {
+			return
+		}
+	}
+}
+
+// trailingPlanPath returns the last depth elements of a plan file's path,
+// without the .json extension.
+func trailingPlanPath(filePath string, depth int) string {
+	elements := strings.Split(strings.TrimSuffix(path.Clean(filepath.ToSlash(filePath)), ".json"), "/")
+	if len(elements) > depth {
+		elements = elements[len(elements)-depth:]
+	}
+	return strings.Join(elements, "/")
+}
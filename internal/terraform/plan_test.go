@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	tfjson "github.com/hashicorp/terraform-json"
 )
 
@@ -232,6 +233,25 @@ func TestProcessChanges(t *testing.T) {
 	}
 }
 
+func TestProcessChangesSkipsNoOpAndRead(t *testing.T) {
+	changes := []*tfjson.ResourceChange{
+		{Address: "aws_vpc.main", Mode: tfjson.ManagedResourceMode, Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}}},
+		{Address: "data.aws_ami.ubuntu", Mode: tfjson.DataResourceMode, Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionRead}}},
+		{Address: "data.aws_region.current", Mode: tfjson.DataResourceMode, Change: &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}}},
+	}
+
+	got, err := processChanges(changes, "test.json")
+	if err != nil {
+		t.Fatalf("processChanges() error = %v", err)
+	}
+	if got.HasChanges {
+		t.Error("HasChanges = true, want false")
+	}
+	if diff := cmp.Diff([]string{"aws_vpc.main"}, got.UnchangedResources); diff != "" {
+		t.Errorf("UnchangedResources mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestProcessChangesNilResourceChanges(t *testing.T) {
 	_, err := processChanges(nil, "test.json")
 	if err == nil {
@@ -258,3 +278,70 @@ func TestExtractPlanName(t *testing.T) {
 		})
 	}
 }
+
+func TestUniquePlanNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name:  "distinct_file_names",
+			paths: []string{"prod/prod.json", "staging/staging.json"},
+			want:  []string{"prod", "staging"},
+		},
+		{
+			name:  "same_file_name",
+			paths: []string{"./prod/plan.json", "staging/plan.json", "network.json"},
+			want:  []string{"prod/plan", "staging/plan", "network"},
+		},
+		{
+			name:  "same_parent_directory",
+			paths: []string{"eu/prod/plan.json", "us/prod/plan.json", "staging/plan.json"},
+			want:  []string{"eu/prod/plan", "us/prod/plan", "staging/plan"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans := make([]*PlanWithIdentifier, len(tt.paths))
+			for i, planPath := range tt.paths {
+				plans[i] = &PlanWithIdentifier{Name: extractPlanName(planPath), Path: planPath}
+			}
+			uniquePlanNames(plans)
+			var got []string
+			for _, plan := range plans {
+				got = append(got, plan.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("uniquePlanNames() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResourceDataLineCounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		diff        string
+		wantAdded   int
+		wantRemoved int
+	}{
+		{name: "empty", diff: "", wantAdded: 0, wantRemoved: 0},
+		{name: "create", diff: "+ami = \"one\"\n+name = \"web\"", wantAdded: 2, wantRemoved: 0},
+		{name: "update", diff: " ami = \"one\"\n-name = \"old\"\n+name = \"new\"\n tags = {\n-  Team = \"a\"\n }", wantAdded: 1, wantRemoved: 2},
+		{name: "folded", diff: " … 4 unchanged lines\n-size = 1\n+size = 2", wantAdded: 1, wantRemoved: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := &ResourceData{Diff: tt.diff}
+			if got := resource.LinesAdded(); got != tt.wantAdded {
+				t.Errorf("LinesAdded() = %d, want %d", got, tt.wantAdded)
+			}
+			if got := resource.LinesRemoved(); got != tt.wantRemoved {
+				t.Errorf("LinesRemoved() = %d, want %d", got, tt.wantRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// replacePaths formats the attribute paths that force a resource to be
+// replaced, such as "engine_version" or "ingress[0].cidr_blocks".
+func replacePaths(paths []interface{}) []string {
+	var result []string
+	for _, path := range paths {
+		steps, ok := path.([]interface{})
+		if !ok || len(steps) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		for _, step := range steps {
+			switch step := step.(type) {
+			case string:
+				if sb.Len() > 0 {
+					sb.WriteString(".")
+				}
+				sb.WriteString(step)
+			case float64:
+				fmt.Fprintf(&sb, "[%d]", int(step))
+			}
+		}
+		result = append(result, sb.String())
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReplacePaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []interface{}
+		want  []string
+	}{
+		{name: "none", paths: nil, want: nil},
+		{name: "attribute", paths: []interface{}{[]interface{}{"engine_version"}}, want: []string{"engine_version"}},
+		{
+			name: "nested",
+			paths: []interface{}{
+				[]interface{}{"ingress", float64(0), "cidr_blocks"},
+				[]interface{}{"availability_zone"},
+			},
+			want: []string{"ingress[0].cidr_blocks", "availability_zone"},
+		},
+		{name: "malformed", paths: []interface{}{"engine_version", []interface{}{}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, replacePaths(tt.paths)); diff != "" {
+				t.Errorf("replacePaths() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
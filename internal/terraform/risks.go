@@ -0,0 +1,46 @@
+package terraform
+
+import (
+	"slices"
+	"strings"
+)
+
+// RiskyChange is a boolean attribute flipped in the direction that removes a
+// safeguard, such as disabling deletion protection.
+type RiskyChange struct {
+	Attribute string
+	Before    bool
+	After     bool
+	Reason    string
+}
+
+var riskyFlips = []RiskyChange{
+	{Attribute: "deletion_protection", Before: true, After: false, Reason: "deletion protection is disabled"},
+	{Attribute: "enable_deletion_protection", Before: true, After: false, Reason: "deletion protection is disabled"},
+	{Attribute: "skip_final_snapshot", Before: false, After: true, Reason: "no final snapshot will be taken on deletion"},
+	{Attribute: "force_destroy", Before: false, After: true, Reason: "the resource can be destroyed with its contents"},
+	{Attribute: "publicly_accessible", Before: false, After: true, Reason: "the resource becomes publicly accessible"},
+	{Attribute: "storage_encrypted", Before: true, After: false, Reason: "storage encryption is disabled"},
+	{Attribute: "encrypted", Before: true, After: false, Reason: "encryption is disabled"},
+}
+
+// detectRiskyChanges returns the risky flips among the top-level attributes
+// of a resource, sorted by attribute name.
+func detectRiskyChanges(before, after map[string]interface{}) []*RiskyChange {
+	var result []*RiskyChange
+	for _, flip := range riskyFlips {
+		was, ok := before[flip.Attribute].(bool)
+		if !ok || was != flip.Before {
+			continue
+		}
+		now, ok := after[flip.Attribute].(bool)
+		if !ok || now != flip.After {
+			continue
+		}
+		result = append(result, &flip)
+	}
+	slices.SortFunc(result, func(a, b *RiskyChange) int {
+		return strings.Compare(a.Attribute, b.Attribute)
+	})
+	return result
+}
@@ -0,0 +1,50 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectRiskyChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   []string
+	}{
+		{
+			name:   "safeguards removed",
+			before: map[string]interface{}{"deletion_protection": true, "skip_final_snapshot": false},
+			after:  map[string]interface{}{"deletion_protection": false, "skip_final_snapshot": true},
+			want:   []string{"deletion_protection", "skip_final_snapshot"},
+		},
+		{
+			name:   "safeguards added",
+			before: map[string]interface{}{"deletion_protection": false, "publicly_accessible": true},
+			after:  map[string]interface{}{"deletion_protection": true, "publicly_accessible": false},
+		},
+		{
+			name:   "created with safeguards off",
+			before: map[string]interface{}{},
+			after:  map[string]interface{}{"force_destroy": true},
+		},
+		{
+			name:   "unknown after apply",
+			before: map[string]interface{}{"encrypted": true},
+			after:  map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, risky := range detectRiskyChanges(tt.before, tt.after) {
+				got = append(got, risky.Attribute)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("detectRiskyChanges() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// IndexShift describes the churn caused by removing an element from the
+// middle of a count list: every later instance takes the values of its
+// successor and the last instance is destroyed.
+type IndexShift struct {
+	Base        string
+	Removed     string
+	RemovedDiff string
+	Shifted     []string
+	Deleted     string
+}
+
+// detectIndexShifts finds count-indexed resources whose changes are a shift of
+// their siblings' values down by one index, ending in the deletion of the last
+// instance.
+func detectIndexShifts(updated, recreated, deleted []*tfjson.ResourceChange) []*IndexShift {
+	changedByBase := make(map[string]map[int]*tfjson.ResourceChange)
+	for _, changes := range [][]*tfjson.ResourceChange{updated, recreated} {
+		for _, change := range changes {
+			if base, index, ok := countIndex(change); ok {
+				if changedByBase[base] == nil {
+					changedByBase[base] = make(map[int]*tfjson.ResourceChange)
+				}
+				changedByBase[base][index] = change
+			}
+		}
+	}
+
+	var shifts []*IndexShift
+	for _, tail := range deleted {
+		base, last, ok := countIndex(tail)
+		if !ok {
+			continue
+		}
+		changed := changedByBase[base]
+
+		first := last
+		for first > 0 {
+			change, ok := changed[first-1]
+			if !ok {
+				break
+			}
+			next := tail
+			if first < last {
+				next = changed[first]
+			}
+			if !isShiftedFrom(change, next) {
+				break
+			}
+			first--
+		}
+		if first == last {
+			continue
+		}
+
+		removed := changed[first]
+		before, _ := removed.Change.Before.(map[string]interface{})
+		removedDiff, _ := generateDiff(before, map[string]interface{}{}, removed.Change.BeforeSensitive, nil)
+
+		shift := &IndexShift{
+			Base:        base,
+			Removed:     removed.Address,
+			RemovedDiff: removedDiff,
+			Deleted:     tail.Address,
+		}
+		for index := first; index < last; index++ {
+			shift.Shifted = append(shift.Shifted, changed[index].Address)
+		}
+		shifts = append(shifts, shift)
+	}
+
+	slices.SortFunc(shifts, func(a, b *IndexShift) int {
+		return strings.Compare(a.Base, b.Base)
+	})
+	return shifts
+}
+
+// isShiftedFrom reports whether every known attribute the change modifies
+// takes the value the next sibling had before the plan.
+func isShiftedFrom(change, next *tfjson.ResourceChange) bool {
+	before, _ := change.Change.Before.(map[string]interface{})
+	after, _ := change.Change.After.(map[string]interface{})
+	nextBefore, _ := next.Change.Before.(map[string]interface{})
+
+	modified := 0
+	for _, key := range knownAttributes(after, change.Change.AfterUnknown) {
+		if reflect.DeepEqual(before[key], after[key]) {
+			continue
+		}
+		if !reflect.DeepEqual(after[key], nextBefore[key]) {
+			return false
+		}
+		modified++
+	}
+	return modified > 0
+}
+
+// countIndex returns the address without its count index, and the index, of
+// a resource instance created with count.
+func countIndex(change *tfjson.ResourceChange) (string, int, bool) {
+	index, ok := change.Index.(float64)
+	if !ok {
+		return "", 0, false
+	}
+	suffix := fmt.Sprintf("[%d]", int(index))
+	if !strings.HasSuffix(change.Address, suffix) {
+		return "", 0, false
+	}
+	return strings.TrimSuffix(change.Address, suffix), int(index), true
+}
@@ -0,0 +1,101 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func indexedChange(address string, index int, action tfjson.Action, before, after map[string]interface{}) *tfjson.ResourceChange {
+	change := &tfjson.ResourceChange{
+		Address: address,
+		Index:   float64(index),
+		Change: &tfjson.Change{
+			Actions: []tfjson.Action{action},
+			Before:  before,
+		},
+	}
+	if after != nil {
+		change.Change.After = after
+	}
+	return change
+}
+
+func TestDetectIndexShifts(t *testing.T) {
+	user := func(id, name string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "name": name}
+	}
+
+	tests := []struct {
+		name    string
+		updated []*tfjson.ResourceChange
+		deleted []*tfjson.ResourceChange
+		want    []*IndexShift
+	}{
+		{
+			name: "removal_from_middle",
+			updated: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[1]", 1, tfjson.ActionUpdate, user("bob", "bob"), user("bob", "carol")),
+				indexedChange("aws_iam_user.u[2]", 2, tfjson.ActionUpdate, user("carol", "carol"), user("carol", "dave")),
+			},
+			deleted: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[3]", 3, tfjson.ActionDelete, user("dave", "dave"), nil),
+			},
+			want: []*IndexShift{
+				{
+					Base:        "aws_iam_user.u",
+					Removed:     "aws_iam_user.u[1]",
+					RemovedDiff: "-id   = \"bob\"\n-name = \"bob\"",
+					Shifted:     []string{"aws_iam_user.u[1]", "aws_iam_user.u[2]"},
+					Deleted:     "aws_iam_user.u[3]",
+				},
+			},
+		},
+		{
+			name: "unrelated_update_stops_run",
+			updated: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[0]", 0, tfjson.ActionUpdate, user("alice", "alice"), user("alice", "alicia")),
+				indexedChange("aws_iam_user.u[1]", 1, tfjson.ActionUpdate, user("bob", "bob"), user("bob", "carol")),
+			},
+			deleted: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[2]", 2, tfjson.ActionDelete, user("carol", "carol"), nil),
+			},
+			want: []*IndexShift{
+				{
+					Base:        "aws_iam_user.u",
+					Removed:     "aws_iam_user.u[1]",
+					RemovedDiff: "-id   = \"bob\"\n-name = \"bob\"",
+					Shifted:     []string{"aws_iam_user.u[1]"},
+					Deleted:     "aws_iam_user.u[2]",
+				},
+			},
+		},
+		{
+			name: "removal_of_last_element",
+			deleted: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[2]", 2, tfjson.ActionDelete, user("carol", "carol"), nil),
+			},
+			want: nil,
+		},
+		{
+			name: "values_not_shifted",
+			updated: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[1]", 1, tfjson.ActionUpdate, user("bob", "bob"), user("bob", "robert")),
+			},
+			deleted: []*tfjson.ResourceChange{
+				indexedChange("aws_iam_user.u[2]", 2, tfjson.ActionDelete, user("carol", "carol"), nil),
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectIndexShifts(tt.updated, nil, tt.deleted)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("detectIndexShifts() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
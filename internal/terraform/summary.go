@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"slices"
+	"strings"
+)
+
+const defaultRegistryPrefix = "registry.terraform.io/"
+
+// SummaryRow counts the changes of one resource type or provider.
+type SummaryRow struct {
+	Name    string
+	Summary ChangeSummary
+}
+
+// summarizeBy counts the plan's changes per key, sorted by key.
+func summarizeBy(planData *PlanData, key func(*ResourceData) string) []*SummaryRow {
+	rows := make(map[string]*SummaryRow)
+	for _, resources := range [][]*ResourceData{
+		planData.CreatedResources,
+		planData.UpdatedResources,
+		planData.RecreatedResources,
+		planData.DeletedResources,
+	} {
+		for _, resource := range resources {
+			name := key(resource)
+			row, ok := rows[name]
+			if !ok {
+				row = &SummaryRow{Name: name}
+				rows[name] = row
+			}
+			row.Summary.add(resource.Action, len(resource.Members()))
+		}
+	}
+	return sortedRows(rows)
+}
+
+// mergeRows adds up rows with the same name across plans.
+func mergeRows(rowSets ...[]*SummaryRow) []*SummaryRow {
+	rows := make(map[string]*SummaryRow)
+	for _, set := range rowSets {
+		for _, row := range set {
+			merged, ok := rows[row.Name]
+			if !ok {
+				merged = &SummaryRow{Name: row.Name}
+				rows[row.Name] = merged
+			}
+			merged.Summary = merged.Summary.plus(row.Summary)
+		}
+	}
+	return sortedRows(rows)
+}
+
+func sortedRows(rows map[string]*SummaryRow) []*SummaryRow {
+	result := make([]*SummaryRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row)
+	}
+	slices.SortFunc(result, func(a, b *SummaryRow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+func resourceType(resource *ResourceData) string {
+	return resource.Type
+}
+
+func resourceProvider(resource *ResourceData) string {
+	return strings.TrimPrefix(resource.ProviderName, defaultRegistryPrefix)
+}
@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProcessMultiplePlansSummaries(t *testing.T) {
+	multiPlanData, err := ProcessMultiplePlans([]string{
+		filepath.Join("testdata", "plan-creates-recreate.json"),
+		filepath.Join("testdata", "plan-updates-deletes.json"),
+	})
+	if err != nil {
+		t.Fatalf("ProcessMultiplePlans() error = %v", err)
+	}
+
+	wantSummary := ChangeSummary{Create: 3, Update: 2, Recreate: 1, Delete: 2}
+	if multiPlanData.Summary != wantSummary {
+		t.Errorf("Summary = %+v, want %+v", multiPlanData.Summary, wantSummary)
+	}
+
+	wantByType := []*SummaryRow{
+		{Name: "aws_eip_association", Summary: ChangeSummary{Delete: 1}},
+		{Name: "aws_instance", Summary: ChangeSummary{Create: 1, Update: 1}},
+		{Name: "aws_rds_cluster", Summary: ChangeSummary{Recreate: 1}},
+		{Name: "aws_s3_bucket", Summary: ChangeSummary{Delete: 1}},
+		{Name: "aws_security_group", Summary: ChangeSummary{Update: 1}},
+		{Name: "aws_subnet", Summary: ChangeSummary{Create: 1}},
+		{Name: "aws_vpc", Summary: ChangeSummary{Create: 1}},
+	}
+	if diff := cmp.Diff(wantByType, multiPlanData.ByType); diff != "" {
+		t.Errorf("ByType mismatch (-want +got):\n%s", diff)
+	}
+
+	wantByProvider := []*SummaryRow{
+		{Name: "hashicorp/aws", Summary: wantSummary},
+	}
+	if diff := cmp.Diff(wantByProvider, multiPlanData.ByProvider); diff != "" {
+		t.Errorf("ByProvider mismatch (-want +got):\n%s", diff)
+	}
+
+	planSummary := multiPlanData.Plans[0].Data.Summary
+	if planSummary != (ChangeSummary{Create: 3, Recreate: 1}) {
+		t.Errorf("Plans[0] Summary = %+v", planSummary)
+	}
+}
+
+func TestSummarizeByCountsGroupedInstances(t *testing.T) {
+	planData := &PlanData{
+		UpdatedResources: []*ResourceData{
+			{Type: "aws_subnet", Action: "update", Instances: []*ResourceData{{}, {}, {}}},
+			{Type: "aws_vpc", Action: "update"},
+		},
+	}
+
+	got := summarizeBy(planData, resourceType)
+	want := []*SummaryRow{
+		{Name: "aws_subnet", Summary: ChangeSummary{Update: 3}},
+		{Name: "aws_vpc", Summary: ChangeSummary{Update: 1}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("summarizeBy() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -4,4 +4,56 @@ type MRNote struct {
 	ID     int64
 	Body   string
 	Exists bool
+	// Part is the note's position in a series of notes, starting at 1.
+	Part int
+	// Parts is the number of notes in the series.
+	Parts int
+	// StyleChanged is set when the note was posted with another visibility
+	// than the configured one, or as a standalone note when discussions are
+	// configured, which an update cannot change.
+	StyleChanged bool
+	// Duplicate is set for older notes with the marker that are not part of
+	// the latest series.
+	Duplicate bool
+}
+
+// MRChanges describes the changes of a merge request.
+type MRChanges struct {
+	WebURL   string
+	BaseSHA  string
+	StartSHA string
+	HeadSHA  string
+	Files    []*ChangedFile
+}
+
+type ChangedFile struct {
+	OldPath string
+	NewPath string
+	Diff    string
+	Deleted bool
+}
+
+// MRDiscussion is a discussion started by a previous run, identified by the
+// key recorded in its first note.
+type MRDiscussion struct {
+	ID       string
+	NoteID   int64
+	Body     string
+	Key      string
+	Resolved bool
+	// Replied is set when others replied to the first note.
+	Replied  bool
+	Position *DiffPosition
+}
+
+// DiffPosition anchors a discussion to a line of the merge request diff.
+// OldLine is zero for added lines and NewLine for removed ones.
+type DiffPosition struct {
+	BaseSHA  string
+	StartSHA string
+	HeadSHA  string
+	OldPath  string
+	NewPath  string
+	OldLine  int
+	NewLine  int
 }
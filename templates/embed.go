@@ -4,3 +4,9 @@ import _ "embed"
 
 //go:embed plan.md.tmpl
 var PlanTemplateContent string
+
+//go:embed plan.txt.tmpl
+var TextTemplateContent string
+
+//go:embed report.html.tmpl
+var HTMLTemplateContent string